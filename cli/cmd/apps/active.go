@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
-	"github.com/grahamplata/roku-remote/roku"
 	"github.com/spf13/cobra"
 )
 
@@ -21,7 +20,7 @@ This command works even when the device is in Limited mode.`,
 			if err != nil {
 				return err
 			}
-			r := roku.NewDevice(ip)
+			r := ch.NewDevice(ip)
 			activeApp, err := r.ActiveApp(ctx)
 			if err != nil {
 				return fmt.Errorf("error getting active app: %w", err)
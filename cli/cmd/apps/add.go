@@ -5,7 +5,6 @@ import (
 	"strings"
 
 	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
-	"github.com/grahamplata/roku-remote/roku"
 	"github.com/spf13/cobra"
 )
 
@@ -27,7 +26,7 @@ Usage: roku-remote apps add netflix`,
 			if appID == "" {
 				return fmt.Errorf("you must provide an application name or id to add")
 			}
-			device := roku.NewDevice(ip)
+			device := ch.NewDevice(ip)
 			// Check if app already exists
 			apps, err := device.FetchInstalledApps(ctx)
 			if err != nil {
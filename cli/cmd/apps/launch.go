@@ -1,8 +1,8 @@
 package apps
 
 import (
+	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
 	"github.com/grahamplata/roku-remote/roku"
@@ -29,26 +29,16 @@ Use 'roku apps list' to see available applications and their IDs.`,
 			}
 
 			appID := args[0]
-			device := roku.NewDevice(ip)
+			device := ch.NewDevice(ip)
 			// Validate app exists
-			apps, err := device.FetchInstalledApps(ctx)
+			app, err := device.FindApp(ctx, appID)
 			if err != nil {
-				return fmt.Errorf("error fetching apps: %w", err)
-			}
-			var actualID string
-			for _, app := range apps.Apps {
-				if strings.EqualFold(app.ID, appID) {
-					actualID = app.ID
-					break
-				} else if strings.EqualFold(app.Name, appID) {
-					actualID = app.ID
-					break
+				if errors.Is(err, roku.ErrAppNotFound) {
+					return fmt.Errorf("app '%s' not found. Use 'roku apps list' to see available apps", appID)
 				}
+				return fmt.Errorf("error fetching apps: %w", err)
 			}
-			if actualID == "" {
-				return fmt.Errorf("app '%s' not found. Use 'roku apps list' to see available apps", appID)
-			}
-			err = device.Launch(ctx, actualID)
+			err = device.Launch(ctx, app.ID)
 			if err != nil {
 				return fmt.Errorf("error launching app: %w", err)
 			}
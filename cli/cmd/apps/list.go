@@ -5,7 +5,6 @@ import (
 	"sort"
 
 	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
-	"github.com/grahamplata/roku-remote/roku"
 	"github.com/spf13/cobra"
 )
 
@@ -22,7 +21,7 @@ Usage: roku-remote apps list`,
 			if err != nil {
 				return err
 			}
-			r := roku.NewDevice(ip)
+			r := ch.NewDevice(ip)
 			apps, err := r.FetchInstalledApps(ctx)
 			if err != nil {
 				return fmt.Errorf("error fetching apps: %w", err)
@@ -0,0 +1,107 @@
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/spf13/cobra"
+)
+
+func SearchCmd(ch *cmdutil.Helper) *cobra.Command {
+	var searchCmd = &cobra.Command{
+		Use:   "search <keyword>",
+		Short: "Search for content on your Roku.",
+		Long: `Open Roku search results for a movie, show, person, channel or game, or
+start playing it directly with --launch.
+
+Examples:
+  roku search "The Office"
+  roku search "The Office" --type tv-show --season 3 --provider 12 --launch
+  roku search "Top Gun: Maverick" --exact --provider netflix,plex
+
+Providers are channel IDs or names from 'roku apps list'.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			flags := cmd.Flags()
+			exact, err := flags.GetBool("exact")
+			if err != nil {
+				return fmt.Errorf("unable to complete (search) command: %w", err)
+			}
+			opts := api.SearchOptions{}
+			if opts.Type, err = flags.GetString("type"); err != nil {
+				return fmt.Errorf("unable to complete (search) command: %w", err)
+			}
+			if opts.Season, err = flags.GetInt("season"); err != nil {
+				return fmt.Errorf("unable to complete (search) command: %w", err)
+			}
+			if opts.TMSID, err = flags.GetString("tmsid"); err != nil {
+				return fmt.Errorf("unable to complete (search) command: %w", err)
+			}
+			if opts.Launch, err = flags.GetBool("launch"); err != nil {
+				return fmt.Errorf("unable to complete (search) command: %w", err)
+			}
+			if opts.MatchAny, err = flags.GetBool("match-any"); err != nil {
+				return fmt.Errorf("unable to complete (search) command: %w", err)
+			}
+			providers, err := flags.GetStringSlice("provider")
+			if err != nil {
+				return fmt.Errorf("unable to complete (search) command: %w", err)
+			}
+			name, err := flags.GetString("device")
+			if err != nil {
+				return fmt.Errorf("unable to complete (search) command: %w", err)
+			}
+			query := strings.Join(args, " ")
+			if exact {
+				opts.Title = query
+			} else {
+				opts.Keyword = query
+			}
+
+			ip, err := ch.ResolveDevice(name)
+			if err != nil {
+				return err
+			}
+			device := ch.NewDevice(ip)
+			for _, provider := range providers {
+				if _, err := strconv.Atoi(provider); err == nil {
+					opts.ProviderIDs = append(opts.ProviderIDs, provider)
+					continue
+				}
+				app, err := device.FindApp(ctx, provider)
+				if err != nil {
+					if errors.Is(err, roku.ErrAppNotFound) {
+						return fmt.Errorf("provider '%s' not found. Use 'roku apps list' to see available apps", provider)
+					}
+					return fmt.Errorf("error fetching apps: %w", err)
+				}
+				opts.ProviderIDs = append(opts.ProviderIDs, app.ID)
+			}
+
+			if err := device.Search(ctx, opts); err != nil {
+				return fmt.Errorf("error searching: %w", err)
+			}
+			if opts.Launch {
+				fmt.Printf("Launching '%s'.\n", query)
+			} else {
+				fmt.Printf("Showing results for '%s'.\n", query)
+			}
+			return nil
+		},
+	}
+	searchCmd.Flags().String("type", "", "Content type: "+strings.Join(api.SearchTypes, ", "))
+	searchCmd.Flags().Int("season", 0, "Season of a tv-show")
+	searchCmd.Flags().String("tmsid", "", "Gracenote TMS ID of the content")
+	searchCmd.Flags().StringSlice("provider", nil, "Channel IDs or names to prefer, in order")
+	searchCmd.Flags().Bool("exact", false, "Match the title exactly instead of as a keyword")
+	searchCmd.Flags().Bool("launch", false, "Play the content in the first provider that has it")
+	searchCmd.Flags().Bool("match-any", false, "Use the first match when several titles match")
+	searchCmd.Flags().StringP("device", "d", "", "Device name or IP, the default device when empty")
+	return searchCmd
+}
@@ -0,0 +1,229 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/history"
+	"github.com/grahamplata/roku-remote/roku/schedule"
+	"github.com/grahamplata/roku-remote/roku/usage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// scheduleStateFile records the last time the daemon checked the schedule
+const scheduleStateFile = "schedule-state.json"
+
+func DaemonCmd(ch *cmdutil.Helper) *cobra.Command {
+	var daemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "Run scheduled actions, screen-time tracking and history in the foreground.",
+		Long: `Run the rules added with 'roku schedule add' until interrupted, and record
+the viewing time of every configured device for 'roku usage report',
+enforcing any daily limits from the config file. App sessions are recorded
+for 'roku history'.
+
+The schedule and limits are reread from the config file on every check, so
+they can be changed while the daemon runs. Rules that fell due while the machine
+was asleep or the daemon was stopped run on wake if they are less than
+--grace late, and are otherwise logged as missed. Runs are logged to
+schedule.log in the roku-remote config directory, see 'roku schedule log'.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			interval, err := cmd.Flags().GetDuration("interval")
+			if err != nil {
+				return fmt.Errorf("unable to complete (daemon) command: %w", err)
+			}
+			grace, err := cmd.Flags().GetDuration("grace")
+			if err != nil {
+				return fmt.Errorf("unable to complete (daemon) command: %w", err)
+			}
+			track, err := cmd.Flags().GetBool("track")
+			if err != nil {
+				return fmt.Errorf("unable to complete (daemon) command: %w", err)
+			}
+			sampleInterval, err := cmd.Flags().GetDuration("sample-interval")
+			if err != nil {
+				return fmt.Errorf("unable to complete (daemon) command: %w", err)
+			}
+			warning, err := cmd.Flags().GetDuration("warning")
+			if err != nil {
+				return fmt.Errorf("unable to complete (daemon) command: %w", err)
+			}
+			recordHistory, err := cmd.Flags().GetBool("history")
+			if err != nil {
+				return fmt.Errorf("unable to complete (daemon) command: %w", err)
+			}
+			dir, err := ch.DataDir()
+			if err != nil {
+				return err
+			}
+			runLog, err := os.OpenFile(filepath.Join(dir, scheduleLogFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				return fmt.Errorf("error opening run log: %w", err)
+			}
+			defer runLog.Close()
+
+			// The scheduler and tracker reread the config from their own
+			// goroutines, and viper isn't safe for concurrent use
+			var configMu sync.Mutex
+			s := newScheduler(ch, &configMu, interval, grace, dir, runLog)
+			fmt.Printf("Running scheduled actions, logging to %s\n", runLog.Name())
+
+			// The tracker and history recorder share one poll of each device
+			sampler := &roku.Sampler{Interval: sampleInterval}
+			if track || recordHistory {
+				ips, err := ch.ConfiguredDevices()
+				if err != nil {
+					return err
+				}
+				for _, ip := range ips {
+					sampler.Devices = append(sampler.Devices, ch.NewDevice(ip))
+				}
+			}
+			if track {
+				limits, err := ch.Limits()
+				if err != nil {
+					return err
+				}
+				tracker, err := newTracker(ch, &configMu, sampleInterval, warning)
+				if err != nil {
+					return err
+				}
+				sampler.Observers = append(sampler.Observers, tracker.Observe)
+				fmt.Printf("Tracking screen time on %d device(s) with %d limit(s)\n", len(sampler.Devices), len(limits))
+			}
+			if recordHistory {
+				recorder, err := newRecorder(ch, sampleInterval)
+				if err != nil {
+					return err
+				}
+				// Runs after the sampler has stopped, as the errors are
+				// collected below before returning
+				defer recorder.Close()
+				sampler.Observers = append(sampler.Observers, recorder.Observe)
+				fmt.Printf("Recording app history on %d device(s)\n", len(sampler.Devices))
+			}
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			errs := make(chan error, 2)
+			go func() { errs <- s.Run(ctx) }()
+			running := 1
+			if len(sampler.Observers) > 0 {
+				go func() { errs <- sampler.Run(ctx) }()
+				running++
+			}
+			// Stop everything as soon as one part fails
+			var firstErr error
+			for ; running > 0; running-- {
+				if err := <-errs; err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+			}
+			return firstErr
+		},
+	}
+	daemonCmd.Flags().Duration("interval", schedule.DefaultInterval, "How often to check the schedule")
+	daemonCmd.Flags().Duration("grace", schedule.DefaultGrace, "How late a missed action may still run")
+	daemonCmd.Flags().Bool("track", true, "Record screen time and enforce limits")
+	daemonCmd.Flags().Bool("history", true, "Record app sessions for 'roku history'")
+	daemonCmd.Flags().Duration("sample-interval", usage.DefaultInterval, "How often to sample devices for screen time and history")
+	daemonCmd.Flags().Duration("warning", usage.DefaultWarning, "Time between a limit warning and power off")
+	return daemonCmd
+}
+
+// newScheduler creates a scheduler for the rules in the config file. Every
+// config access holds configMu, which the tracker shares.
+func newScheduler(ch *cmdutil.Helper, configMu *sync.Mutex, interval, grace time.Duration, dir string, runLog io.Writer) *schedule.Scheduler {
+	logger := ch.Logger()
+	return &schedule.Scheduler{
+		Rules: func() ([]schedule.Rule, error) {
+			configMu.Lock()
+			defer configMu.Unlock()
+			if err := rereadConfig(); err != nil {
+				return nil, err
+			}
+			return ch.ScheduleRules()
+		},
+		Exec: func(ctx context.Context, rule schedule.Rule) error {
+			configMu.Lock()
+			ip, err := ch.ResolveDevice(rule.Device)
+			configMu.Unlock()
+			if err == nil {
+				err = runRule(ctx, ch, ip, rule)
+			}
+			if err != nil {
+				logger.Warn("scheduled action failed", "rule", rule.Spec, "error", err)
+			} else {
+				logger.Info("scheduled action ran", "rule", rule.Spec)
+			}
+			return err
+		},
+		Done: func(rule schedule.Rule) error {
+			configMu.Lock()
+			defer configMu.Unlock()
+			return removeRule(ch, rule)
+		},
+		Interval:  interval,
+		Grace:     grace,
+		StatePath: filepath.Join(dir, scheduleStateFile),
+		Log:       runLog,
+		Logger:    logger,
+	}
+}
+
+// rereadConfig reloads the config file so edits apply while the daemon runs
+func rereadConfig() error {
+	if viper.ConfigFileUsed() == "" {
+		return nil
+	}
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	return nil
+}
+
+// newTracker creates a screen-time tracker that rereads the limits from the
+// config file, holding configMu, on every round
+func newTracker(ch *cmdutil.Helper, configMu *sync.Mutex, interval, warning time.Duration) (*usage.Tracker, error) {
+	store, err := openUsageStore(ch)
+	if err != nil {
+		return nil, err
+	}
+	return &usage.Tracker{
+		Store: store,
+		Limits: func() ([]usage.Limit, error) {
+			configMu.Lock()
+			defer configMu.Unlock()
+			if err := rereadConfig(); err != nil {
+				return nil, err
+			}
+			return ch.Limits()
+		},
+		Interval: interval,
+		Warning:  warning,
+		Logger:   ch.Logger(),
+	}, nil
+}
+
+// newRecorder creates a history recorder
+func newRecorder(ch *cmdutil.Helper, interval time.Duration) (*history.Recorder, error) {
+	log, err := openHistory(ch)
+	if err != nil {
+		return nil, err
+	}
+	return &history.Recorder{
+		Log:      log,
+		Interval: interval,
+		Logger:   ch.Logger(),
+	}, nil
+}
@@ -0,0 +1,56 @@
+package automation
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku/schedule"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Exec resolves devices from the config while the tracker rereads it, which
+// the race detector reports unless both hold the config lock
+func TestScheduler_ExecWhileLimitsReload(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	config := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(config, []byte("roku:\n  host: 127.0.0.1\n  names:\n    den: 127.0.0.1\n"), 0o644))
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.SetConfigFile(config)
+	require.NoError(t, viper.ReadInConfig())
+
+	ch := &cmdutil.Helper{}
+	var configMu sync.Mutex
+	s := newScheduler(ch, &configMu, time.Minute, time.Minute, dir, io.Discard)
+	tracker, err := newTracker(ch, &configMu, time.Minute, 0)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			_, err := tracker.Limits()
+			assert.NoError(t, err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		// An unknown device fails in ResolveDevice without touching the network
+		rule := schedule.Rule{Spec: "at 22:00 poweroff on attic", Action: "poweroff", Device: "attic"}
+		for range 50 {
+			assert.ErrorContains(t, s.Exec(context.Background(), rule), "unknown device")
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,176 @@
+package automation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku/history"
+	"github.com/spf13/cobra"
+)
+
+// historyFile is the session log kept in the data directory
+const historyFile = "history.jsonl"
+
+func HistoryCmd(ch *cmdutil.Helper) *cobra.Command {
+	var historyCmd = &cobra.Command{
+		Use:   "history",
+		Short: "Show the app sessions recorded by the daemon.",
+		Long: `Show the app sessions recorded by 'roku daemon', newest last. A session
+lasts from an app being opened until it is closed or the device turns off.
+Where the media player reports it, the plugin, stream length and format of
+what was playing is included. ECP doesn't expose programme titles.
+
+Examples:
+  roku history                          # the last 7 days
+  roku history --app netflix --days 30
+  roku history --from 2026-01-01 --to 2026-01-31 --format csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, err := cmd.Flags().GetString("device")
+			if err != nil {
+				return fmt.Errorf("unable to complete (history) command: %w", err)
+			}
+			app, err := cmd.Flags().GetString("app")
+			if err != nil {
+				return fmt.Errorf("unable to complete (history) command: %w", err)
+			}
+			days, err := cmd.Flags().GetInt("days")
+			if err != nil {
+				return fmt.Errorf("unable to complete (history) command: %w", err)
+			}
+			fromFlag, err := cmd.Flags().GetString("from")
+			if err != nil {
+				return fmt.Errorf("unable to complete (history) command: %w", err)
+			}
+			toFlag, err := cmd.Flags().GetString("to")
+			if err != nil {
+				return fmt.Errorf("unable to complete (history) command: %w", err)
+			}
+			outputFormat, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf("unable to complete (history) command: %w", err)
+			}
+
+			filter := history.Filter{App: app}
+			if name != "" {
+				if filter.Device, err = ch.ResolveDevice(name); err != nil {
+					return err
+				}
+			}
+			if days < 1 {
+				return fmt.Errorf("days must be at least 1, got %d", days)
+			}
+			today := time.Now()
+			today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+			filter.From = today.AddDate(0, 0, 1-days)
+			if fromFlag != "" {
+				if filter.From, err = time.ParseInLocation(time.DateOnly, fromFlag, time.Local); err != nil {
+					return fmt.Errorf("invalid --from date %q, expected YYYY-MM-DD", fromFlag)
+				}
+			}
+			if toFlag != "" {
+				to, err := time.ParseInLocation(time.DateOnly, toFlag, time.Local)
+				if err != nil {
+					return fmt.Errorf("invalid --to date %q, expected YYYY-MM-DD", toFlag)
+				}
+				// Include the whole of the last day
+				filter.To = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+
+			log, err := openHistory(ch)
+			if err != nil {
+				return err
+			}
+			sessions, err := log.Query(filter)
+			if err != nil {
+				return err
+			}
+
+			switch outputFormat {
+			case "table":
+				printHistory(sessions)
+				return nil
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				for _, s := range sessions {
+					if err := enc.Encode(s); err != nil {
+						return fmt.Errorf("error writing history: %w", err)
+					}
+				}
+				return nil
+			case "csv":
+				w := csv.NewWriter(os.Stdout)
+				_ = w.Write([]string{"device", "app_id", "app", "start", "end", "seconds", "played_seconds", "plugin_id", "plugin_name", "content_seconds", "live", "video", "resolution"})
+				for _, s := range sessions {
+					c := s.Content
+					if c == nil {
+						c = &history.Content{}
+					}
+					_ = w.Write([]string{
+						s.Device, s.AppID, s.App,
+						s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339),
+						strconv.FormatInt(int64(s.Duration().Seconds()), 10),
+						strconv.FormatInt(s.PlayedSeconds, 10),
+						c.PluginID, c.PluginName,
+						strconv.FormatInt(c.DurationSeconds, 10),
+						strconv.FormatBool(c.Live),
+						c.Video, c.Resolution,
+					})
+				}
+				w.Flush()
+				return w.Error()
+			default:
+				return fmt.Errorf("unknown format %q, expected table, csv or json", outputFormat)
+			}
+		},
+	}
+	historyCmd.Flags().StringP("device", "d", "", "Only show this device name or IP")
+	historyCmd.Flags().String("app", "", "Only show this app id or name")
+	historyCmd.Flags().Int("days", 7, "Number of days to show, ending today")
+	historyCmd.Flags().String("from", "", "First day to show, YYYY-MM-DD (overrides --days)")
+	historyCmd.Flags().String("to", "", "Last day to show, YYYY-MM-DD")
+	historyCmd.Flags().String("format", "table", "Output format: table, csv or json")
+	return historyCmd
+}
+
+// printHistory prints one line per session
+func printHistory(sessions []history.Session) {
+	if len(sessions) == 0 {
+		fmt.Println("No sessions recorded. Run 'roku daemon' to start recording history.")
+		return
+	}
+	for _, s := range sessions {
+		line := fmt.Sprintf("%s-%s  %-15s  %-24s %7s",
+			s.Start.Local().Format("2006-01-02 15:04"), s.End.Local().Format("15:04"),
+			s.Device, s.App, hours(s.Duration()))
+		if s.PlayedSeconds > 0 {
+			line += fmt.Sprintf("  played %s", hours(time.Duration(s.PlayedSeconds)*time.Second))
+		}
+		if c := s.Content; c != nil {
+			switch {
+			case c.Live:
+				line += "  live"
+			case c.DurationSeconds > 0:
+				line += fmt.Sprintf("  %s stream", hours(time.Duration(c.DurationSeconds)*time.Second))
+			}
+			if c.Resolution != "" {
+				line += " " + c.Resolution
+			}
+		}
+		fmt.Println(line)
+	}
+}
+
+// openHistory returns the session log in the data directory
+func openHistory(ch *cmdutil.Helper) (*history.Log, error) {
+	dir, err := ch.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return history.NewLog(filepath.Join(dir, historyFile)), nil
+}
@@ -0,0 +1,123 @@
+package automation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/scene"
+	"github.com/spf13/cobra"
+)
+
+func SceneCmd(ch *cmdutil.Helper) *cobra.Command {
+	var sceneCmd = &cobra.Command{
+		Use:   "scene [name]",
+		Short: "Run a named multi-device preset from the config file.",
+		Long: `Run a scene, a named set of steps across devices defined under scenes in
+the config file. Devices run at the same time and each device's steps run in
+order. If a step fails the rest of that device's steps are skipped. Without a
+name the configured scenes are listed.
+
+Steps are a key such as poweron or HDMI2, optionally repeated with xN,
+"launch <app>", "input <label>", "volume <level>" or "wait <duration>":
+
+  scenes:
+    movie-night:
+      - device: living-room
+        steps:
+          - poweron
+          - wait 5s
+          - input PlayStation
+          - volume 20
+          - launch Plex
+      - device: 192.168.1.21
+        steps:
+          - poweroff
+
+Devices are IPs or names from roku.names in the config file.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pause, err := cmd.Flags().GetDuration("pause")
+			if err != nil {
+				return fmt.Errorf("unable to complete (scene) command: %w", err)
+			}
+			scenes, err := ch.Scenes()
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				printScenes(scenes)
+				return nil
+			}
+
+			name := strings.ToLower(args[0])
+			s, ok := scenes[name]
+			if !ok {
+				return fmt.Errorf("no scene named %q, see 'roku scene' for the configured scenes", args[0])
+			}
+
+			var mu sync.Mutex
+			runner := &scene.Runner{
+				Resolve: func(name string) (*roku.Device, error) {
+					ip, err := ch.ResolveDevice(name)
+					if err != nil {
+						return nil, err
+					}
+					return ch.NewDevice(ip), nil
+				},
+				Pause:       pause,
+				VolumeSteps: ch.VolumeSteps,
+				Report: func(r scene.Result) {
+					status := "ok"
+					switch {
+					case r.Skipped:
+						status = "skipped"
+					case r.Err != nil:
+						status = "failed"
+					}
+					line := fmt.Sprintf("%-7s  %-15s  %s", status, r.Device, r.Step.Spec)
+					if r.Err != nil {
+						line += ": " + r.Err.Error()
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					fmt.Println(line)
+				},
+			}
+			err = runner.Run(cmd.Context(), s)
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				// Each failure was already reported with its step
+				return fmt.Errorf("scene %s failed on %d of %d device(s)", s.Name, len(joined.Unwrap()), len(s.Parts))
+			}
+			return err
+		},
+	}
+	sceneCmd.Flags().Duration("pause", scene.DefaultPause, "Delay between repeated keypresses")
+	return sceneCmd
+}
+
+// printScenes lists each scene with its steps per device
+func printScenes(scenes map[string]scene.Scene) {
+	if len(scenes) == 0 {
+		fmt.Println("No scenes configured. Add them under scenes in the config file, see 'roku scene --help'.")
+		return
+	}
+	names := make([]string, 0, len(scenes))
+	for name := range scenes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Println(name)
+		for _, part := range scenes[name].Parts {
+			steps := make([]string, len(part.Steps))
+			for i, step := range part.Steps {
+				steps[i] = step.Spec
+			}
+			fmt.Printf("  %-15s  %s\n", part.Device, strings.Join(steps, ", "))
+		}
+	}
+}
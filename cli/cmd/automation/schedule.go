@@ -0,0 +1,218 @@
+package automation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku/schedule"
+	"github.com/spf13/cobra"
+)
+
+// scheduleLogFile is the run log kept in the data directory
+const scheduleLogFile = "schedule.log"
+
+func ScheduleCmd(ch *cmdutil.Helper) *cobra.Command {
+	var scheduleCmd = &cobra.Command{
+		Use:   "schedule",
+		Short: "Manage timed actions run by the daemon.",
+		Long: `Manage rules that press a key or launch an app at a time of day. Rules are
+stored in the config file and run by 'roku daemon'.
+
+Rules have the form [every <days>] HH:MM <action> [args] [--device <name>],
+where days is day, weekday, weekend or a list such as mon,wed,fri. Rules
+without "every" run once and are then removed. Devices are IPs or names from
+roku.names in the config file.`,
+	}
+	scheduleCmd.AddCommand(
+		scheduleAddCmd(ch),
+		scheduleListCmd(ch),
+		scheduleRemoveCmd(ch),
+		scheduleLogCmd(ch),
+	)
+	return scheduleCmd
+}
+
+func scheduleAddCmd(ch *cmdutil.Helper) *cobra.Command {
+	var addCmd = &cobra.Command{
+		Use:   "add <rule>",
+		Short: "Add a scheduled action.",
+		Long: `Add a scheduled action.
+
+Examples:
+  roku schedule add "22:30 poweroff --device kids"
+  roku schedule add "every weekday 07:00 launch news"
+  roku schedule add "every sat,sun 09:00 launch youtube" --device living-room`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			device, err := cmd.Flags().GetString("device")
+			if err != nil {
+				return fmt.Errorf("unable to complete (schedule add) command: %w", err)
+			}
+			spec := strings.Join(args, " ")
+			if device != "" {
+				spec += " --device " + device
+			}
+			rule, err := schedule.Parse(spec)
+			if err != nil {
+				return err
+			}
+			if _, err := ch.ResolveDevice(rule.Device); err != nil {
+				return err
+			}
+
+			entries, err := ch.ScheduleEntries()
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			entries = append(entries, cmdutil.ScheduleEntry{Spec: rule.Spec, Created: now.Format(time.RFC3339)})
+			if err := ch.SaveScheduleEntries(entries); err != nil {
+				return err
+			}
+			fmt.Printf("Scheduled %q, next run %s\n", rule.Command(), rule.Next(now).Format("Mon Jan 2 15:04"))
+			return nil
+		},
+	}
+	addCmd.Flags().StringP("device", "d", "", "Device name or IP to run the action on")
+	return addCmd
+}
+
+func scheduleListCmd(ch *cmdutil.Helper) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List scheduled actions.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rules, err := ch.ScheduleRules()
+			if err != nil {
+				return err
+			}
+			if len(rules) == 0 {
+				fmt.Println("No scheduled actions. Add one with 'roku schedule add'.")
+				return nil
+			}
+			now := time.Now()
+			for i, rule := range rules {
+				next := rule.Next(now)
+				if !rule.Repeat {
+					next = rule.Next(rule.Created)
+				}
+				fmt.Printf("%2d. %-45s next %s\n", i+1, rule.Spec, next.Format("Mon Jan 2 15:04"))
+			}
+			return nil
+		},
+	}
+}
+
+func scheduleRemoveCmd(ch *cmdutil.Helper) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <number>",
+		Short: "Remove a scheduled action by its number in 'roku schedule list'.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := ch.ScheduleEntries()
+			if err != nil {
+				return err
+			}
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 || n > len(entries) {
+				return fmt.Errorf("invalid rule number %q, expected 1 to %d", args[0], len(entries))
+			}
+			removed := entries[n-1]
+			entries = append(entries[:n-1], entries[n:]...)
+			if err := ch.SaveScheduleEntries(entries); err != nil {
+				return err
+			}
+			fmt.Printf("Removed %q\n", removed.Spec)
+			return nil
+		},
+	}
+}
+
+func scheduleLogCmd(ch *cmdutil.Helper) *cobra.Command {
+	var logCmd = &cobra.Command{
+		Use:   "log",
+		Short: "Show recent runs of scheduled actions.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			count, err := cmd.Flags().GetInt("count")
+			if err != nil {
+				return fmt.Errorf("unable to complete (schedule log) command: %w", err)
+			}
+			dir, err := ch.DataDir()
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(filepath.Join(dir, scheduleLogFile))
+			if errors.Is(err, os.ErrNotExist) {
+				fmt.Println("No scheduled actions have run yet.")
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("error opening run log: %w", err)
+			}
+			defer f.Close()
+
+			var records []schedule.Record
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				var record schedule.Record
+				if err := json.Unmarshal(scanner.Bytes(), &record); err == nil {
+					records = append(records, record)
+				}
+			}
+			if len(records) > count {
+				records = records[len(records)-count:]
+			}
+			for _, r := range records {
+				line := fmt.Sprintf("%s  %-6s  %s", r.Time.Local().Format("2006-01-02 15:04"), r.Status, r.Rule)
+				if r.Status == schedule.StatusMissed {
+					line += fmt.Sprintf(" (was due %s)", r.Scheduled.Local().Format("15:04"))
+				}
+				if r.Error != "" {
+					line += ": " + r.Error
+				}
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+	logCmd.Flags().IntP("count", "n", 20, "Number of runs to show")
+	return logCmd
+}
+
+// removeRule deletes a completed one-shot rule from the config
+func removeRule(ch *cmdutil.Helper, rule schedule.Rule) error {
+	entries, err := ch.ScheduleEntries()
+	if err != nil {
+		return err
+	}
+	created := rule.Created.Format(time.RFC3339)
+	for i, entry := range entries {
+		if entry.Spec == rule.Spec && entry.Created == created {
+			return ch.SaveScheduleEntries(append(entries[:i], entries[i+1:]...))
+		}
+	}
+	return nil
+}
+
+// runRule performs a rule's action on the device at ip, resolved from the
+// rule by the caller
+func runRule(ctx context.Context, ch *cmdutil.Helper, ip string, rule schedule.Rule) error {
+	device := ch.NewDevice(ip)
+	if rule.Action != "launch" {
+		return device.Action(ctx, rule.Action)
+	}
+	app, err := device.FindApp(ctx, strings.Join(rule.Args, " "))
+	if err != nil {
+		return err
+	}
+	return device.Launch(ctx, app.ID)
+}
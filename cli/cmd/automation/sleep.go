@@ -0,0 +1,84 @@
+package automation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/cli/pkg/format"
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/spf13/cobra"
+)
+
+func SleepCmd(ch *cmdutil.Helper) *cobra.Command {
+	var sleepCmd = &cobra.Command{
+		Use:   "sleep <duration>",
+		Short: "Power off a Roku after a delay.",
+		Long: `Count down in the foreground, then power off the device. Press ctrl+c to
+cancel. The countdown follows the wall clock, so it still fires on time if
+the computer sleeps in between.
+
+Examples:
+  roku sleep 45m
+  roku sleep 1h30m --device kids
+  roku sleep 20m --action home`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			delay, err := time.ParseDuration(args[0])
+			if err != nil || delay <= 0 {
+				return fmt.Errorf("invalid duration %q, expected a value such as 45m or 1h30m", args[0])
+			}
+			name, err := cmd.Flags().GetString("device")
+			if err != nil {
+				return fmt.Errorf("unable to complete (sleep) command: %w", err)
+			}
+			action, err := cmd.Flags().GetString("action")
+			if err != nil {
+				return fmt.Errorf("unable to complete (sleep) command: %w", err)
+			}
+			key, err := api.ParseKey(action)
+			if err != nil {
+				return fmt.Errorf("unknown action %q", action)
+			}
+			ip, err := ch.ResolveDevice(name)
+			if err != nil {
+				return err
+			}
+			device := ch.NewDevice(ip)
+			// Refuse up front rather than count down to a keypress the model ignores
+			if err := device.CheckKey(ctx, key); errors.Is(err, roku.ErrUnsupported) {
+				return fmt.Errorf("%w, pick another --action such as home", err)
+			}
+
+			deadline := time.Now().Add(delay).Round(0)
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				remaining := time.Until(deadline)
+				if remaining <= 0 {
+					break
+				}
+				fmt.Printf("\r%s %s in %s ", action, ip, format.Duration(remaining+time.Second-1))
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					fmt.Println("\nSleep timer cancelled.")
+					return nil
+				}
+			}
+			fmt.Println()
+
+			if err := device.Keypress(ctx, key); err != nil {
+				return fmt.Errorf("error sending %s: %w", action, err)
+			}
+			fmt.Printf("Sent %s to %s.\n", action, ip)
+			return nil
+		},
+	}
+	sleepCmd.Flags().StringP("device", "d", "", "Device name or IP, the default device when empty")
+	sleepCmd.Flags().String("action", "poweroff", "Key to send when the timer ends")
+	return sleepCmd
+}
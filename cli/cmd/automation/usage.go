@@ -0,0 +1,205 @@
+package automation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/cli/pkg/format"
+	"github.com/grahamplata/roku-remote/roku/usage"
+	"github.com/spf13/cobra"
+)
+
+// usageStoreFile holds the accumulated viewing time in the data directory
+const usageStoreFile = "usage.json"
+
+func UsageCmd(ch *cmdutil.Helper) *cobra.Command {
+	var usageCmd = &cobra.Command{
+		Use:   "usage",
+		Short: "Report screen time recorded by the daemon.",
+		Long: `Report the viewing time per device and app recorded by 'roku daemon'.
+
+Daily limits are set under limits in the config file, per device or per app:
+
+  limits:
+    - device: kids
+      daily: 2h
+    - device: kids
+      app: YouTube
+      daily: 45m
+
+When a limit is reached the device is sent to the home screen, and if it is
+still in use two minutes later (--warning on the daemon) it is powered off.
+App limits only send the device home.`,
+	}
+	usageCmd.AddCommand(usageReportCmd(ch))
+	return usageCmd
+}
+
+func usageReportCmd(ch *cmdutil.Helper) *cobra.Command {
+	var reportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Show viewing time per device and app.",
+		Long: `Show viewing time per device and app.
+
+Examples:
+  roku usage report             # today
+  roku usage report --week      # the last 7 days
+  roku usage report --days 30 --device kids --format csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			week, err := cmd.Flags().GetBool("week")
+			if err != nil {
+				return fmt.Errorf("unable to complete (usage report) command: %w", err)
+			}
+			days, err := cmd.Flags().GetInt("days")
+			if err != nil {
+				return fmt.Errorf("unable to complete (usage report) command: %w", err)
+			}
+			name, err := cmd.Flags().GetString("device")
+			if err != nil {
+				return fmt.Errorf("unable to complete (usage report) command: %w", err)
+			}
+			outputFormat, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf("unable to complete (usage report) command: %w", err)
+			}
+			if week {
+				days = 7
+			}
+			if days < 1 {
+				return fmt.Errorf("days must be at least 1, got %d", days)
+			}
+			var deviceIP string
+			if name != "" {
+				if deviceIP, err = ch.ResolveDevice(name); err != nil {
+					return err
+				}
+			}
+
+			store, err := openUsageStore(ch)
+			if err != nil {
+				return err
+			}
+			to := time.Now()
+			from := to.AddDate(0, 0, 1-days)
+			var entries []usage.Entry
+			for _, entry := range store.Report(from, to) {
+				if deviceIP == "" || entry.Device == deviceIP {
+					entries = append(entries, entry)
+				}
+			}
+
+			switch outputFormat {
+			case "table":
+				printUsage(entries, days)
+				return nil
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				for _, e := range entries {
+					if err := enc.Encode(map[string]any{
+						"day": e.Day, "device": e.Device, "app_id": e.AppID, "app": e.App, "seconds": int64(e.Duration.Seconds()),
+					}); err != nil {
+						return fmt.Errorf("error writing report: %w", err)
+					}
+				}
+				return nil
+			case "csv":
+				w := csv.NewWriter(os.Stdout)
+				_ = w.Write([]string{"day", "device", "app_id", "app", "seconds"})
+				for _, e := range entries {
+					_ = w.Write([]string{e.Day, e.Device, e.AppID, e.App, strconv.FormatInt(int64(e.Duration.Seconds()), 10)})
+				}
+				w.Flush()
+				return w.Error()
+			default:
+				return fmt.Errorf("unknown format %q, expected table, csv or json", outputFormat)
+			}
+		},
+	}
+	reportCmd.Flags().Bool("week", false, "Report the last 7 days")
+	reportCmd.Flags().Int("days", 1, "Number of days to report, ending today")
+	reportCmd.Flags().StringP("device", "d", "", "Only report this device name or IP")
+	reportCmd.Flags().String("format", "table", "Output format: table, csv or json")
+	return reportCmd
+}
+
+// printUsage prints totals per device with a breakdown by app and day
+func printUsage(entries []usage.Entry, days int) {
+	if len(entries) == 0 {
+		fmt.Println("No viewing time recorded. Run 'roku daemon' to start tracking.")
+		return
+	}
+	byDevice := make(map[string][]usage.Entry)
+	var devices []string
+	for _, e := range entries {
+		if _, ok := byDevice[e.Device]; !ok {
+			devices = append(devices, e.Device)
+		}
+		byDevice[e.Device] = append(byDevice[e.Device], e)
+	}
+	sort.Strings(devices)
+
+	for _, device := range devices {
+		var total time.Duration
+		apps := make(map[string]time.Duration)
+		names := make(map[string]string)
+		perDay := make(map[string]time.Duration)
+		for _, e := range byDevice[device] {
+			total += e.Duration
+			apps[e.AppID] += e.Duration
+			names[e.AppID] = e.App
+			perDay[e.Day] += e.Duration
+		}
+		fmt.Printf("%s  total %s", device, hours(total))
+		if days > 1 {
+			fmt.Printf(", average %s/day", hours(total/time.Duration(days)))
+		}
+		fmt.Println()
+
+		ids := make([]string, 0, len(apps))
+		for id := range apps {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return apps[ids[i]] > apps[ids[j]] })
+		for _, id := range ids {
+			fmt.Printf("  %-24s %8s  %3.0f%%\n", names[id], hours(apps[id]), 100*apps[id].Seconds()/total.Seconds())
+		}
+
+		if days > 1 {
+			dayKeys := make([]string, 0, len(perDay))
+			var values []float64
+			for day := range perDay {
+				dayKeys = append(dayKeys, day)
+			}
+			sort.Strings(dayKeys)
+			for _, day := range dayKeys {
+				values = append(values, perDay[day].Hours())
+			}
+			fmt.Printf("  by day %s  (%s to %s)\n", format.Sparkline(values, 0), dayKeys[0], dayKeys[len(dayKeys)-1])
+		}
+		fmt.Println()
+	}
+}
+
+// hours renders a duration as 1h05m or 12m
+func hours(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d >= time.Hour {
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
+
+func openUsageStore(ch *cmdutil.Helper) (*usage.Store, error) {
+	dir, err := ch.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return usage.Open(filepath.Join(dir, usageStoreFile))
+}
@@ -0,0 +1,270 @@
+package dev
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku/debug"
+	"github.com/spf13/cobra"
+)
+
+// maxConsoleLines caps the scrollback kept in the UI
+const maxConsoleLines = 5000
+
+const consoleHelp = "enter: send  f5: cont  f10: step  f6: bt  f7: var  pgup/pgdown: scroll  ctrl+c: quit"
+
+var (
+	timeStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	errorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	warningStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	backtraceStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("14"))
+	debuggerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("13")).Bold(true)
+	statusStyle    = lipgloss.NewStyle().Faint(true).Italic(true)
+)
+
+func ConsoleCmd(ch *cmdutil.Helper) *cobra.Command {
+	var consoleCmd = &cobra.Command{
+		Use:   "console",
+		Short: "Attach to the BrightScript debug console.",
+		Long: `Stream the BrightScript debug console on port 8085 with timestamps and
+highlighting, and send debugger commands such as bt, var, cont and step.
+
+The console reconnects automatically when the channel is restarted.
+
+Examples:
+  roku dev console
+  roku dev console --save session.log
+  roku dev console --plain | grep ERROR`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			ip, err := ch.ValidateRokuHost()
+			if err != nil {
+				return err
+			}
+			savePath, err := cmd.Flags().GetString("save")
+			if err != nil {
+				return fmt.Errorf("unable to complete (console) command: %w", err)
+			}
+			plain, err := cmd.Flags().GetBool("plain")
+			if err != nil {
+				return fmt.Errorf("unable to complete (console) command: %w", err)
+			}
+
+			var save io.Writer = io.Discard
+			if savePath != "" {
+				f, err := os.OpenFile(savePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+				if err != nil {
+					return fmt.Errorf("error opening session file: %w", err)
+				}
+				defer f.Close()
+				save = f
+			}
+
+			console := debug.NewConsole(ip)
+			if plain {
+				return runPlainConsole(ctx, console, save)
+			}
+			p := tea.NewProgram(newConsoleModel(ctx, console, save), tea.WithAltScreen())
+			if _, err := p.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+	consoleCmd.Flags().String("save", "", "Append the session to this file")
+	consoleCmd.Flags().Bool("plain", false, "Print output without the interactive UI, reading commands from stdin")
+	return consoleCmd
+}
+
+// runPlainConsole streams the console to stdout and forwards stdin lines as commands
+func runPlainConsole(ctx context.Context, console *debug.Console, save io.Writer) error {
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if err := console.Send(scanner.Text()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error sending command: %v\n", err)
+			}
+		}
+	}()
+	for line := range console.Stream(ctx) {
+		fmt.Println(line.String())
+		fmt.Fprintln(save, line.String())
+	}
+	return nil
+}
+
+// consoleModel is the interactive debug console UI
+type consoleModel struct {
+	ctx     context.Context
+	console *debug.Console
+	lines   <-chan debug.Line
+	save    io.Writer
+
+	viewport viewport.Model
+	input    textinput.Model
+	rendered []string
+	ready    bool
+}
+
+// lineMsg carries a line of console output
+type lineMsg debug.Line
+
+// streamClosedMsg is sent when the console stream ends
+type streamClosedMsg struct{}
+
+func newConsoleModel(ctx context.Context, console *debug.Console, save io.Writer) *consoleModel {
+	input := textinput.New()
+	input.Placeholder = "debugger command (bt, var, cont, step...)"
+	input.Prompt = "> "
+	input.Focus()
+	return &consoleModel{
+		ctx:     ctx,
+		console: console,
+		lines:   console.Stream(ctx),
+		save:    save,
+		input:   input,
+	}
+}
+
+func (m *consoleModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.waitForLine())
+}
+
+// waitForLine reads the next line from the console stream
+func (m *consoleModel) waitForLine() tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-m.lines
+		if !ok {
+			return streamClosedMsg{}
+		}
+		return lineMsg(line)
+	}
+}
+
+func (m *consoleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		height := msg.Height - 3
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width, height)
+			m.ready = true
+		} else {
+			m.viewport.Width = msg.Width
+			m.viewport.Height = height
+		}
+		m.input.Width = msg.Width - 4
+		m.refresh()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "enter":
+			command := strings.TrimSpace(m.input.Value())
+			m.input.Reset()
+			if command != "" {
+				m.send(command)
+			}
+			return m, nil
+		case "f5":
+			m.send("cont")
+			return m, nil
+		case "f6":
+			m.send("bt")
+			return m, nil
+		case "f7":
+			m.send("var")
+			return m, nil
+		case "f10":
+			m.send("step")
+			return m, nil
+		case "pgup", "pgdown":
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+	case lineMsg:
+		m.append(debug.Line(msg))
+		cmds = append(cmds, m.waitForLine())
+	case streamClosedMsg:
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+// send forwards a command to the console and echoes it into the scrollback
+func (m *consoleModel) send(command string) {
+	if err := m.console.Send(command); err != nil {
+		m.append(debug.Line{Text: fmt.Sprintf("error sending %q: %v", command, err), Kind: debug.KindStatus})
+		return
+	}
+	fmt.Fprintf(m.save, "> %s\n", command)
+}
+
+// append adds a line to the scrollback and session file
+func (m *consoleModel) append(line debug.Line) {
+	if !line.Time.IsZero() {
+		fmt.Fprintln(m.save, line.String())
+	}
+	m.rendered = append(m.rendered, renderLine(line))
+	if len(m.rendered) > maxConsoleLines {
+		m.rendered = m.rendered[len(m.rendered)-maxConsoleLines:]
+	}
+	m.refresh()
+}
+
+// refresh redraws the viewport, following the tail unless scrolled up
+func (m *consoleModel) refresh() {
+	if !m.ready {
+		return
+	}
+	atBottom := m.viewport.AtBottom()
+	m.viewport.SetContent(strings.Join(m.rendered, "\n"))
+	if atBottom {
+		m.viewport.GotoBottom()
+	}
+}
+
+// renderLine highlights a console line by kind
+func renderLine(line debug.Line) string {
+	var style lipgloss.Style
+	switch line.Kind {
+	case debug.KindError:
+		style = errorStyle
+	case debug.KindWarning:
+		style = warningStyle
+	case debug.KindBacktrace:
+		style = backtraceStyle
+	case debug.KindDebugger:
+		style = debuggerStyle
+	case debug.KindStatus:
+		style = statusStyle
+	default:
+		style = lipgloss.NewStyle()
+	}
+	timestamp := "            "
+	if !line.Time.IsZero() {
+		timestamp = line.Time.Format("15:04:05.000")
+	}
+	return timeStyle.Render(timestamp) + " " + style.Render(line.Text)
+}
+
+func (m *consoleModel) View() string {
+	if !m.ready {
+		return "Connecting to debug console..."
+	}
+	return fmt.Sprintf("%s\n%s\n%s", m.viewport.View(), m.input.View(), timeStyle.Render(consoleHelp))
+}
@@ -0,0 +1,26 @@
+package dev
+
+import (
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func DevCmd(ch *cmdutil.Helper) *cobra.Command {
+	var devCmd = &cobra.Command{
+		Use:   "dev",
+		Short: "Tools for developing channels on a dev-mode Roku.",
+		Long: `Tools for developing channels on a Roku with developer mode enabled.
+
+Commands that use the developer installer authenticate as rokudev with the
+password from roku.dev_password in the config file or ROKU_DEV_PASSWORD.`,
+	}
+	devCmd.AddCommand(
+		ConsoleCmd(ch),
+		GfxCmd(ch),
+		NodesCmd(ch),
+		PerfCmd(ch),
+		RegistryCmd(ch),
+		ScreenshotCmd(ch),
+	)
+	return devCmd
+}
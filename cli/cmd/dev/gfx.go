@@ -0,0 +1,99 @@
+package dev
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/cli/pkg/format"
+	"github.com/spf13/cobra"
+)
+
+func GfxCmd(ch *cmdutil.Helper) *cobra.Command {
+	var gfxCmd = &cobra.Command{
+		Use:   "gfx",
+		Short: "Report texture memory and frame rate of a channel.",
+		Long: `Report the largest texture allocations of a channel from
+/query/r2d2-bitmaps, then sample the frame rate and texture memory over time
+from /query/graphics-frame-rate.
+
+Examples:
+  roku dev gfx                        # Top textures, then sample every second
+  roku dev gfx --top 20 --count 1     # Top 20 textures only
+  roku dev gfx --interval 500ms`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			ip, err := ch.ValidateRokuHost()
+			if err != nil {
+				return err
+			}
+			appID, err := cmd.Flags().GetString("app")
+			if err != nil {
+				return fmt.Errorf("unable to complete (gfx) command: %w", err)
+			}
+			top, err := cmd.Flags().GetInt("top")
+			if err != nil {
+				return fmt.Errorf("unable to complete (gfx) command: %w", err)
+			}
+			interval, err := cmd.Flags().GetDuration("interval")
+			if err != nil {
+				return fmt.Errorf("unable to complete (gfx) command: %w", err)
+			}
+			count, err := cmd.Flags().GetInt("count")
+			if err != nil {
+				return fmt.Errorf("unable to complete (gfx) command: %w", err)
+			}
+			if interval <= 0 {
+				return fmt.Errorf("interval must be positive, got %s", interval)
+			}
+
+			r := ch.NewDevice(ip)
+			bitmaps, err := r.Bitmaps(ctx, appID)
+			if err != nil {
+				return fmt.Errorf("error getting bitmaps: %w", err)
+			}
+			fmt.Printf("Texture memory: %s used of %s (%d bitmaps)\n\n", format.Bytes(bitmaps.Sizes.Used), format.Bytes(bitmaps.Sizes.Max), len(bitmaps.Bitmaps))
+			for i, b := range bitmaps.Top(top) {
+				fmt.Printf("%3d. %10s  %4dx%-4d  %s\n", i+1, format.Bytes(b.Size), b.Width, b.Height, b.Name)
+			}
+			if count == 1 {
+				return nil
+			}
+
+			fmt.Println()
+			var history []float64
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for n := 1; count == 0 || n < count; n++ {
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return nil
+				}
+				frameRate, err := r.FrameRate(ctx)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return fmt.Errorf("error getting frame rate: %w", err)
+				}
+				bitmaps, err := r.Bitmaps(ctx, appID)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return fmt.Errorf("error getting bitmaps: %w", err)
+				}
+				history = appendSample(history, frameRate.FPS)
+				fmt.Printf("%s  %5.1f fps %-*s  textures %s\n", time.Now().Format("15:04:05"), frameRate.FPS,
+					perfHistory, format.Sparkline(history, perfHistory), format.Bytes(bitmaps.Sizes.Used))
+			}
+			return nil
+		},
+	}
+	gfxCmd.Flags().String("app", "dev", "App ID to inspect, dev for the sideloaded channel")
+	gfxCmd.Flags().Int("top", 10, "Number of texture consumers to list")
+	gfxCmd.Flags().Duration("interval", time.Second, "Time between frame rate samples")
+	gfxCmd.Flags().Int("count", 0, "Stop after this many samples, including the initial report (0 runs until interrupted)")
+	return gfxCmd
+}
@@ -0,0 +1,139 @@
+package dev
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/spf13/cobra"
+)
+
+func NodesCmd(ch *cmdutil.Helper) *cobra.Command {
+	var nodesCmd = &cobra.Command{
+		Use:   "nodes",
+		Short: "Print the SceneGraph node tree of the dev channel.",
+		Long: `Print the SceneGraph node tree of the running dev channel using
+/query/sgnodes. Focused nodes are marked with *.
+
+Examples:
+  roku dev nodes                    # All nodes
+  roku dev nodes --roots            # Root nodes only
+  roku dev nodes --node-id homeGrid # A single node and its children
+  roku dev nodes --type RowList     # Subtrees of every RowList
+  roku dev nodes --json > nodes.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			ip, err := ch.ValidateRokuHost()
+			if err != nil {
+				return err
+			}
+			roots, err := cmd.Flags().GetBool("roots")
+			if err != nil {
+				return fmt.Errorf("unable to complete (nodes) command: %w", err)
+			}
+			nodeID, err := cmd.Flags().GetString("node-id")
+			if err != nil {
+				return fmt.Errorf("unable to complete (nodes) command: %w", err)
+			}
+			nodeType, err := cmd.Flags().GetString("type")
+			if err != nil {
+				return fmt.Errorf("unable to complete (nodes) command: %w", err)
+			}
+			filterID, err := cmd.Flags().GetString("id")
+			if err != nil {
+				return fmt.Errorf("unable to complete (nodes) command: %w", err)
+			}
+			asJSON, err := cmd.Flags().GetBool("json")
+			if err != nil {
+				return fmt.Errorf("unable to complete (nodes) command: %w", err)
+			}
+
+			r := ch.NewDevice(ip)
+			var nodes *api.SGNodes
+			switch {
+			case nodeID != "":
+				nodes, err = r.SGNode(ctx, nodeID)
+			case roots:
+				nodes, err = r.SGNodesRoots(ctx)
+			default:
+				nodes, err = r.SGNodesAll(ctx)
+			}
+			if err != nil {
+				return fmt.Errorf("error getting nodes: %w", err)
+			}
+
+			if nodeType != "" || filterID != "" {
+				var matched []api.SGNode
+				for _, node := range nodes.Find(func(node *api.SGNode) bool {
+					return (nodeType == "" || strings.EqualFold(node.Type, nodeType)) &&
+						(filterID == "" || node.ID() == filterID)
+				}) {
+					matched = append(matched, *node)
+				}
+				nodes = &api.SGNodes{Nodes: matched}
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(nodes)
+			}
+			if len(nodes.Nodes) == 0 {
+				fmt.Println("No nodes found.")
+				return nil
+			}
+			for i := range nodes.Nodes {
+				printNode(&nodes.Nodes[i])
+			}
+			fmt.Printf("\n%d nodes\n", nodes.Count())
+			return nil
+		},
+	}
+	nodesCmd.Flags().Bool("roots", false, "Only show root nodes")
+	nodesCmd.Flags().String("node-id", "", "Query a single node by id")
+	nodesCmd.Flags().String("type", "", "Only show subtrees of nodes of this type")
+	nodesCmd.Flags().String("id", "", "Only show subtrees of nodes with this id")
+	nodesCmd.Flags().Bool("json", false, "Print the tree as JSON")
+	return nodesCmd
+}
+
+// printNode prints a node tree with one indented line per node
+func printNode(root *api.SGNode) {
+	root.Walk(func(node *api.SGNode, depth int) bool {
+		marker := " "
+		if node.Focused() {
+			marker = "*"
+		}
+		line := fmt.Sprintf("%s%s %s", strings.Repeat("  ", depth), marker, node.Type)
+		if id := node.ID(); id != "" {
+			line += fmt.Sprintf(" id=%q", id)
+		}
+		if rc := node.RefCount(); rc >= 0 {
+			line += fmt.Sprintf(" rc=%d", rc)
+		}
+		fmt.Println(line + nodeExtras(node))
+		return true
+	})
+}
+
+// nodeExtras renders the remaining attributes in a stable order
+func nodeExtras(node *api.SGNode) string {
+	var keys []string
+	for k := range node.Attributes {
+		switch k {
+		case "id", "name", "rc", "focused":
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%q", k, node.Attributes[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,220 @@
+package dev
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/cli/pkg/format"
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/spf13/cobra"
+)
+
+// perfHistory is how many samples the sparklines show
+const perfHistory = 60
+
+func PerfCmd(ch *cmdutil.Helper) *cobra.Command {
+	var perfCmd = &cobra.Command{
+		Use:   "perf",
+		Short: "Sample channel CPU and memory usage over time.",
+		Long: `Sample the CPU and memory usage of a channel with /query/chanperf.
+
+By default a live sparkline view is shown. Use --format csv or json to write
+one sample per line instead, for example to graph a run later.
+
+Examples:
+  roku dev perf --interval 1s
+  roku dev perf --app 12 --format csv --count 300 > netflix.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			ip, err := ch.ValidateRokuHost()
+			if err != nil {
+				return err
+			}
+			interval, err := cmd.Flags().GetDuration("interval")
+			if err != nil {
+				return fmt.Errorf("unable to complete (perf) command: %w", err)
+			}
+			appID, err := cmd.Flags().GetString("app")
+			if err != nil {
+				return fmt.Errorf("unable to complete (perf) command: %w", err)
+			}
+			outputFormat, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return fmt.Errorf("unable to complete (perf) command: %w", err)
+			}
+			count, err := cmd.Flags().GetInt("count")
+			if err != nil {
+				return fmt.Errorf("unable to complete (perf) command: %w", err)
+			}
+			if interval <= 0 {
+				return fmt.Errorf("interval must be positive, got %s", interval)
+			}
+
+			device := ch.NewDevice(ip)
+			switch outputFormat {
+			case "tui":
+				p := tea.NewProgram(newPerfModel(ctx, device, appID, interval))
+				_, err := p.Run()
+				return err
+			case "csv", "json":
+				return writePerfSamples(ctx, os.Stdout, device, appID, interval, count, outputFormat)
+			default:
+				return fmt.Errorf("unknown format %q, expected tui, csv or json", outputFormat)
+			}
+		},
+	}
+	perfCmd.Flags().Duration("interval", time.Second, "Time between samples")
+	perfCmd.Flags().String("app", "dev", "App ID to sample, dev for the sideloaded channel")
+	perfCmd.Flags().String("format", "tui", "Output format: tui, csv or json")
+	perfCmd.Flags().Int("count", 0, "Stop after this many samples (csv and json only, 0 runs until interrupted)")
+	return perfCmd
+}
+
+// perfSample is a timestamped chanperf reading
+type perfSample struct {
+	Time time.Time        `json:"time"`
+	Perf *api.ChannelPerf `json:"perf"`
+}
+
+// writePerfSamples samples at interval and writes each reading as CSV or JSON lines
+func writePerfSamples(ctx context.Context, w io.Writer, device *roku.Device, appID string, interval time.Duration, count int, outputFormat string) error {
+	csvWriter := csv.NewWriter(w)
+	enc := json.NewEncoder(w)
+	if outputFormat == "csv" {
+		_ = csvWriter.Write([]string{"time", "cpu_user", "cpu_sys", "mem_used", "mem_res", "mem_anon", "mem_file", "mem_shared", "mem_swap"})
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for n := 0; count == 0 || n < count; n++ {
+		perf, err := device.ChannelPerf(ctx, appID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("error sampling channel: %w", err)
+		}
+		now := time.Now()
+		if outputFormat == "csv" {
+			m := perf.Plugin.Memory
+			_ = csvWriter.Write([]string{
+				now.Format(time.RFC3339),
+				strconv.FormatFloat(perf.Plugin.CPU.User, 'f', 2, 64),
+				strconv.FormatFloat(perf.Plugin.CPU.Sys, 'f', 2, 64),
+				strconv.FormatInt(m.Used, 10),
+				strconv.FormatInt(m.Res, 10),
+				strconv.FormatInt(m.Anon, 10),
+				strconv.FormatInt(m.File, 10),
+				strconv.FormatInt(m.Shared, 10),
+				strconv.FormatInt(m.Swap, 10),
+			})
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return fmt.Errorf("error writing sample: %w", err)
+			}
+		} else if err := enc.Encode(perfSample{Time: now, Perf: perf}); err != nil {
+			return fmt.Errorf("error writing sample: %w", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// perfModel is the live sparkline view
+type perfModel struct {
+	ctx      context.Context
+	device   *roku.Device
+	appID    string
+	interval time.Duration
+
+	cpu    []float64
+	memory []float64
+	last   *api.ChannelPerf
+	err    error
+}
+
+// perfSampleMsg carries the result of one chanperf request
+type perfSampleMsg struct {
+	perf *api.ChannelPerf
+	err  error
+}
+
+func newPerfModel(ctx context.Context, device *roku.Device, appID string, interval time.Duration) *perfModel {
+	return &perfModel{ctx: ctx, device: device, appID: appID, interval: interval}
+}
+
+func (m *perfModel) Init() tea.Cmd {
+	return m.sample
+}
+
+func (m *perfModel) sample() tea.Msg {
+	perf, err := m.device.ChannelPerf(m.ctx, m.appID)
+	return perfSampleMsg{perf: perf, err: err}
+}
+
+func (m *perfModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Check for context cancellation
+	select {
+	case <-m.ctx.Done():
+		return m, tea.Quit
+	default:
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		}
+	case perfSampleMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.last = msg.perf
+			m.cpu = appendSample(m.cpu, msg.perf.CPUPercent())
+			m.memory = appendSample(m.memory, float64(msg.perf.Plugin.Memory.Used))
+		}
+		return m, tea.Tick(m.interval, func(time.Time) tea.Msg { return m.sample() })
+	}
+	return m, nil
+}
+
+// appendSample adds v to the history, dropping the oldest beyond perfHistory
+func appendSample(history []float64, v float64) []float64 {
+	history = append(history, v)
+	if len(history) > perfHistory {
+		history = history[len(history)-perfHistory:]
+	}
+	return history
+}
+
+func (m *perfModel) View() string {
+	s := fmt.Sprintf("Channel performance for %s (every %s)\n\n", m.appID, m.interval)
+	if m.last != nil {
+		mem := m.last.Plugin.Memory
+		s += fmt.Sprintf("CPU    %-*s %5.1f%% (user %.1f%%, sys %.1f%%)\n", perfHistory, format.Sparkline(m.cpu, perfHistory),
+			m.last.CPUPercent(), m.last.Plugin.CPU.User, m.last.Plugin.CPU.Sys)
+		s += fmt.Sprintf("Memory %-*s %s (res %s, swap %s)\n", perfHistory, format.Sparkline(m.memory, perfHistory),
+			format.Bytes(mem.Used), format.Bytes(mem.Res), format.Bytes(mem.Swap))
+	} else if m.err == nil {
+		s += "Waiting for first sample...\n"
+	}
+	if m.err != nil {
+		s += fmt.Sprintf("\nError: %v\n", m.err)
+	}
+	s += "\nPress q to quit.\n"
+	return s
+}
@@ -0,0 +1,79 @@
+package dev
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/spf13/cobra"
+)
+
+func RegistryCmd(ch *cmdutil.Helper) *cobra.Command {
+	var registryCmd = &cobra.Command{
+		Use:   "registry [app-id]",
+		Short: "Show a channel's persistent registry.",
+		Long: `Show the registry sections and keys a channel has stored, using
+/query/registry. The app ID defaults to dev, the sideloaded channel.
+
+Examples:
+  roku dev registry
+  roku dev registry dev --section auth
+  roku dev registry dev --json > registry.json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			ip, err := ch.ValidateRokuHost()
+			if err != nil {
+				return err
+			}
+			section, err := cmd.Flags().GetString("section")
+			if err != nil {
+				return fmt.Errorf("unable to complete (registry) command: %w", err)
+			}
+			asJSON, err := cmd.Flags().GetBool("json")
+			if err != nil {
+				return fmt.Errorf("unable to complete (registry) command: %w", err)
+			}
+			appID := "dev"
+			if len(args) == 1 {
+				appID = args[0]
+			}
+
+			r := ch.NewDevice(ip)
+			registry, err := r.Registry(ctx, appID)
+			if err != nil {
+				return fmt.Errorf("error getting registry: %w", err)
+			}
+			if section != "" {
+				s := registry.Section(section)
+				if s == nil {
+					return fmt.Errorf("section '%s' not found in registry for %s", section, appID)
+				}
+				registry.Sections = []api.RegistrySection{*s}
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(registry)
+			}
+			fmt.Printf("Registry for %s (%d bytes available)\n", registry.PluginID, registry.SpaceAvailable)
+			if len(registry.Sections) == 0 {
+				fmt.Println("No sections.")
+				return nil
+			}
+			for _, s := range registry.Sections {
+				fmt.Printf("\n[%s]\n", s.Name)
+				for _, item := range s.Items {
+					fmt.Printf("  %s = %s\n", item.Key, item.Value)
+				}
+			}
+			return nil
+		},
+	}
+	registryCmd.Flags().String("section", "", "Only show this section")
+	registryCmd.Flags().Bool("json", false, "Print the registry as JSON")
+	return registryCmd
+}
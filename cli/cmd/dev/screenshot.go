@@ -0,0 +1,47 @@
+package dev
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func ScreenshotCmd(ch *cmdutil.Helper) *cobra.Command {
+	var screenshotCmd = &cobra.Command{
+		Use:   "screenshot",
+		Short: "Capture a screenshot of the sideloaded channel.",
+		Long: `Capture a screenshot of the running sideloaded channel using the
+developer installer.
+
+Examples:
+  roku dev screenshot               # Writes screenshot.jpg
+  roku dev screenshot -o shot.jpg`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				return fmt.Errorf("unable to complete (screenshot) command: %w", err)
+			}
+			device, err := ch.DeveloperDevice(ctx)
+			if err != nil {
+				return err
+			}
+			shot, err := device.Screenshot(ctx)
+			if err != nil {
+				return fmt.Errorf("error taking screenshot: %w", err)
+			}
+			if output == "" {
+				output = "screenshot." + shot.Ext
+			}
+			if err := os.WriteFile(output, shot.Data, 0o644); err != nil {
+				return fmt.Errorf("error writing screenshot: %w", err)
+			}
+			fmt.Printf("Saved screenshot to %s\n", output)
+			return nil
+		},
+	}
+	screenshotCmd.Flags().StringP("output", "o", "", "File to write the screenshot to (default screenshot.<ext>)")
+	return screenshotCmd
+}
@@ -10,6 +10,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
 	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +18,7 @@ const helpText = `Roku Remote Control
 q: Quit
 p: Power
 +/-: Volume up/down
+]/[: Volume up/down 5
 m: Mute
 Arrow keys: Navigate
 Enter: Select
@@ -33,37 +35,48 @@ Ctrl+F: Find Remote
 Page Up: Channel Up
 Page Down: Channel Down
 t: Tuner
-1-4: HDMI 1-4`
-
-var keyCommands = map[string]string{
-	"p":         "poweroff",
-	"+":         "volumeup",
-	"-":         "volumedown",
-	"m":         "mute",
-	"up":        "up",
-	"down":      "down",
-	"left":      "left",
-	"right":     "right",
-	"enter":     "select",
-	"b":         "back",
-	"h":         "home",
-	"r":         "rev",
-	"f":         "fwd",
-	" ":         "play",
-	"i":         "replay",
-	"tab":       "info",
-	"backspace": "backspace",
-	"/":         "search",
-	"ctrl+f":    "find",
-	"pgup":      "channelup",
-	"pgdown":    "channeldown",
-	"t":         "tuner",
-	"1":         "HDMI1",
-	"2":         "HDMI2",
-	"3":         "HDMI3",
-	"4":         "HDMI4",
+1-4: HDMI 1-4
+a: AV`
+
+var keyCommands = map[string]api.Key{
+	"p":         api.KeyPowerOff,
+	"+":         api.KeyVolumeUp,
+	"-":         api.KeyVolumeDown,
+	"m":         api.KeyVolumeMute,
+	"up":        api.KeyUp,
+	"down":      api.KeyDown,
+	"left":      api.KeyLeft,
+	"right":     api.KeyRight,
+	"enter":     api.KeySelect,
+	"b":         api.KeyBack,
+	"h":         api.KeyHome,
+	"r":         api.KeyRev,
+	"f":         api.KeyFwd,
+	" ":         api.KeyPlay,
+	"i":         api.KeyInstantReplay,
+	"tab":       api.KeyInfo,
+	"backspace": api.KeyBackspace,
+	"/":         api.KeySearch,
+	"ctrl+f":    api.KeyFindRemote,
+	"pgup":      api.KeyChannelUp,
+	"pgdown":    api.KeyChannelDown,
+	"t":         api.KeyInputTuner,
+	"1":         api.KeyInputHDMI1,
+	"2":         api.KeyInputHDMI2,
+	"3":         api.KeyInputHDMI3,
+	"4":         api.KeyInputHDMI4,
+	"a":         api.KeyInputAV1,
 }
 
+// volumeJumps are keys that step the volume several times
+var volumeJumps = map[string]api.Key{
+	"]": api.KeyVolumeUp,
+	"[": api.KeyVolumeDown,
+}
+
+// volumeJump is how many steps a volume jump key moves
+const volumeJump = 5
+
 func ControlCmd(ch *cmdutil.Helper) *cobra.Command {
 	var controlCmd = &cobra.Command{
 		Use:   "control",
@@ -74,8 +87,12 @@ func ControlCmd(ch *cmdutil.Helper) *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("invalid Roku host: %w", err)
 			}
-			device := roku.NewDevice(ip)
-			p := tea.NewProgram(&controlModel{device: device, ctx: ctx})
+			device := ch.NewDevice(ip)
+			model := &controlModel{device: device, ctx: ctx}
+			if caps, err := device.Capabilities(ctx); err == nil {
+				model.caps = &caps
+			}
+			p := tea.NewProgram(model)
 			if _, err := p.Run(); err != nil {
 				return err
 			}
@@ -91,6 +108,8 @@ type controlModel struct {
 	ctx context.Context
 	// device represents the target device to control.
 	device *roku.Device
+	// caps is what the device supports, nil when it couldn't be read.
+	caps *roku.Capabilities
 	// statusMessage displays feedback to the user.
 	statusMessage string
 }
@@ -130,12 +149,12 @@ type clearStatusMsg struct{}
 // errorMsg represents an error from sending a command.
 type errorMsg struct {
 	err error
-	cmd string
+	cmd api.Key
 }
 
 // successMsg represents a successful command send.
 type successMsg struct {
-	cmd string
+	cmd api.Key
 }
 
 func (m controlModel) View() string {
@@ -153,20 +172,55 @@ func (m *controlModel) handleKeyPress(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Map key presses to Roku commands
 		if cmd, exists := keyCommands[msg.String()]; exists {
+			if refused := m.checkKey(cmd); refused != nil {
+				return m, refused
+			}
 			m.statusMessage = fmt.Sprintf("Sending '%s'...", cmd)
 			return m, m.sendCommand(cmd)
 		}
 
+		if cmd, exists := volumeJumps[msg.String()]; exists {
+			if refused := m.checkKey(cmd); refused != nil {
+				return m, refused
+			}
+			m.statusMessage = fmt.Sprintf("Sending '%s' x%d...", cmd, volumeJump)
+			return m, m.sendRepeated(cmd, volumeJump)
+		}
+
 		// Log unknown keys for debugging
 		log.Printf("Unknown key pressed: %s", msg.String())
 	}
 	return m, nil
 }
 
+// checkKey refuses keys the device doesn't support, showing why instead of
+// sending a keypress the device would ignore.
+func (m *controlModel) checkKey(cmd api.Key) tea.Cmd {
+	if m.caps == nil {
+		return nil
+	}
+	if err := m.caps.Check(cmd); err != nil {
+		m.statusMessage = fmt.Sprintf("Not sent: %v", err)
+		return tea.Tick(3*time.Second, func(time.Time) tea.Msg { return clearStatusMsg{} })
+	}
+	return nil
+}
+
 // sendCommand sends a command to the device asynchronously and updates the status.
-func (m *controlModel) sendCommand(cmd string) tea.Cmd {
+func (m *controlModel) sendCommand(cmd api.Key) tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		err := m.device.Keypress(m.ctx, cmd)
+		if err != nil {
+			return errorMsg{err, cmd}
+		}
+		return successMsg{cmd}
+	})
+}
+
+// sendRepeated sends a command several times with the default pacing.
+func (m *controlModel) sendRepeated(cmd api.Key, n int) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
-		err := m.device.Action(m.ctx, cmd)
+		err := m.device.Press(m.ctx, cmd, n, roku.DefaultKeyPause)
 		if err != nil {
 			return errorMsg{err, cmd}
 		}
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
-	"github.com/grahamplata/roku-remote/roku"
 	"github.com/spf13/cobra"
 )
 
@@ -21,7 +20,7 @@ fetches details about the device like make, model and services.`,
 			if err != nil {
 				return err
 			}
-			r := roku.NewDevice(ip)
+			r := ch.NewDevice(ip)
 			info, err := r.Describe(ctx)
 			if err != nil {
 				return fmt.Errorf("error describing device: %w", err)
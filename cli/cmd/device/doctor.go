@@ -0,0 +1,159 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/grahamplata/roku-remote/roku/doctor"
+	"github.com/spf13/cobra"
+)
+
+func DoctorCmd(ch *cmdutil.Helper) *cobra.Command {
+	var doctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the network, device and config file.",
+		Long: `Diagnose the network, the device and the config file, and print a report
+with a fix for each problem found. The checks cover:
+
+  - local interfaces and the multicast route used for discovery
+  - an SSDP search, showing every raw response
+  - TCP and HTTP connections to port 8060, with their latency
+  - the "Control by mobile apps" setting and which commands it allows
+  - the firmware version, and the device's clock and time zone
+  - the settings in the config file
+
+The checks only read from the device. The command fails when any check fails.
+
+Examples:
+  roku doctor
+  roku doctor --device kids --wait 5s`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			name, err := cmd.Flags().GetString("device")
+			if err != nil {
+				return fmt.Errorf("unable to complete (doctor) command: %w", err)
+			}
+			wait, err := cmd.Flags().GetDuration("wait")
+			if err != nil {
+				return fmt.Errorf("unable to complete (doctor) command: %w", err)
+			}
+			return runDoctor(ctx, ch, name, wait)
+		},
+	}
+	doctorCmd.Flags().StringP("device", "d", "", "Device name or IP, the default device when empty")
+	doctorCmd.Flags().Duration("wait", 3*time.Second, "How long to wait for SSDP responses")
+	return doctorCmd
+}
+
+func runDoctor(ctx context.Context, ch *cmdutil.Helper, name string, wait time.Duration) error {
+	var checks []doctor.Check
+	report := func(check doctor.Check) {
+		checks = append(checks, check)
+		fmt.Printf("  %-4s  %-20s  %s\n", check.Status, check.Name, check.Detail)
+		if check.Fix != "" && check.Status != doctor.Pass {
+			fmt.Printf("        %-20s  fix: %s\n", "", check.Fix)
+		}
+	}
+
+	fmt.Println("Config")
+	path, problems := ch.ValidateConfig()
+	config := doctor.Check{Name: "Config file", Status: doctor.Pass, Detail: path}
+	if path == "" {
+		config.Detail = "none loaded"
+	}
+	if len(problems) > 0 {
+		config.Status = doctor.Fail
+		config.Detail = strings.Join(problems, "; ")
+		config.Fix = "Edit the config file, or run 'roku find' to select a device"
+	}
+	report(config)
+	ip, err := ch.ResolveDevice(name)
+	if err != nil {
+		report(doctor.Check{Name: "Device", Status: doctor.Fail, Detail: err.Error(), Fix: "Pass --device with an IP, or run 'roku find'"})
+	}
+	target := net.ParseIP(ip)
+
+	fmt.Println("\nNetwork")
+	ifaces, err := doctor.LocalInterfaces()
+	if err != nil {
+		report(doctor.Check{Name: "Interfaces", Status: doctor.Fail, Detail: err.Error()})
+	} else {
+		report(doctor.Interfaces(ifaces, target))
+	}
+	report(doctor.MulticastRoute())
+	responses, err := doctor.Search(ctx, wait)
+	report(doctor.SSDP(responses, err, target, wait))
+
+	if target != nil {
+		fmt.Printf("\nDevice %s\n", ip)
+		checkDevice(ctx, ch, ip, report)
+	}
+
+	if len(responses) > 0 {
+		fmt.Println("\nSSDP responses")
+		for _, r := range responses {
+			fmt.Printf("  from %s\n", r.From)
+			for _, line := range strings.Split(strings.TrimSpace(r.Raw), "\n") {
+				fmt.Printf("    %s\n", strings.TrimSpace(line))
+			}
+		}
+	}
+
+	failed := 0
+	for _, check := range checks {
+		if check.Status == doctor.Fail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	fmt.Println("\nNo problems found.")
+	return nil
+}
+
+// checkDevice runs the connection, permission, firmware and clock checks,
+// skipping the checks that need the connection when it fails
+func checkDevice(ctx context.Context, ch *cmdutil.Helper, ip string, report func(doctor.Check)) {
+	tcp := doctor.TCP(ctx, net.JoinHostPort(ip, fmt.Sprint(api.RokuPort)))
+	report(tcp)
+	if tcp.Status == doctor.Fail {
+		return
+	}
+	client := &http.Client{Timeout: api.DefaultTimeout, Transport: ch.Transport()}
+	httpCheck, date := doctor.HTTP(ctx, client, fmt.Sprintf("http://%s:%d/", ip, api.RokuPort))
+	report(httpCheck)
+	if httpCheck.Status == doctor.Fail {
+		return
+	}
+
+	device := ch.NewDevice(ip)
+	mode, err := device.Permission(ctx)
+	report(doctor.Permission(mode, err))
+	if err == nil {
+		for _, feature := range roku.Features {
+			status := "ok"
+			if !roku.Allows(mode, feature) {
+				status = "blocked"
+			}
+			fmt.Printf("        %-7s  %s\n", status, feature.Name)
+		}
+	}
+
+	info, err := device.DeviceInfo(ctx)
+	if err != nil {
+		report(doctor.Check{Name: "Firmware", Status: doctor.Skip, Detail: err.Error()})
+		report(doctor.Clock(time.Now(), date, nil))
+		return
+	}
+	report(doctor.Firmware(info))
+	report(doctor.Clock(time.Now(), date, info))
+}
@@ -32,7 +32,7 @@ static configuration, can discover network services.`,
 				return fmt.Errorf("unable to complete (find) command: %w", err)
 			}
 			fmt.Printf("Scanning for Roku devices for %d seconds...\n", wait)
-			devices, err := roku.Find(wait)
+			devices, err := roku.Find(wait, roku.WithLogger(ch.Logger()))
 			if err != nil {
 				return fmt.Errorf("unable to complete (find) command: %w", err)
 			}
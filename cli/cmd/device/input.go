@@ -0,0 +1,101 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/spf13/cobra"
+)
+
+func InputCmd(ch *cmdutil.Helper) *cobra.Command {
+	var inputCmd = &cobra.Command{
+		Use:   "input",
+		Short: "List and switch the inputs of a Roku TV.",
+		Long: `List and switch the inputs of a Roku TV. Inputs are read from the TV with
+the names given to them in its settings, so 'roku input set PlayStation'
+works however many HDMI ports the TV has.
+
+Examples:
+  roku input list
+  roku input set PlayStation
+  roku input set hdmi2 --device bedroom`,
+	}
+	inputCmd.PersistentFlags().StringP("device", "d", "", "Device name or IP, the default device when empty")
+	inputCmd.AddCommand(inputListCmd(ch), inputSetCmd(ch))
+	return inputCmd
+}
+
+func inputListCmd(ch *cmdutil.Helper) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the inputs of the TV with their labels.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			device, err := inputDevice(ch, cmd)
+			if err != nil {
+				return err
+			}
+			inputs, err := device.Inputs(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("error fetching inputs: %w", err)
+			}
+			if len(inputs) == 0 {
+				fmt.Println("No inputs found. Only Roku TVs have inputs.")
+				return nil
+			}
+			for _, input := range inputs {
+				fmt.Printf("%-8s  %-20s  (ID: %s)\n", input.PortName(), input.Label, input.ID)
+			}
+			return nil
+		},
+	}
+}
+
+func inputSetCmd(ch *cmdutil.Helper) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <label-or-port>",
+		Short: "Switch the TV to an input by its label or port.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			name := strings.Join(args, " ")
+			device, err := inputDevice(ch, cmd)
+			if err != nil {
+				return err
+			}
+			if caps, err := device.Capabilities(ctx); err == nil {
+				if err := caps.CheckInputs(); err != nil {
+					return err
+				}
+			}
+			input, err := device.FindInput(ctx, name)
+			if err != nil {
+				if errors.Is(err, roku.ErrInputNotFound) {
+					return fmt.Errorf("input '%s' not found. Use 'roku input list' to see available inputs", name)
+				}
+				return fmt.Errorf("error fetching inputs: %w", err)
+			}
+			if err := device.SetInput(ctx, *input); err != nil {
+				return fmt.Errorf("error switching input: %w", err)
+			}
+			fmt.Printf("Switched to %s (%s).\n", input.Label, input.PortName())
+			return nil
+		},
+	}
+}
+
+// inputDevice returns the device from the input command flags
+func inputDevice(ch *cmdutil.Helper, cmd *cobra.Command) (*roku.Device, error) {
+	name, err := cmd.Flags().GetString("device")
+	if err != nil {
+		return nil, fmt.Errorf("unable to complete (input) command: %w", err)
+	}
+	ip, err := ch.ResolveDevice(name)
+	if err != nil {
+		return nil, err
+	}
+	return ch.NewDevice(ip), nil
+}
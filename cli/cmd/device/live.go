@@ -4,7 +4,7 @@ import (
 	"fmt"
 
 	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
-	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/cli/pkg/format"
 	"github.com/spf13/cobra"
 )
 
@@ -20,20 +20,31 @@ func LiveCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
-			r := roku.NewDevice(ip)
+			r := ch.NewDevice(ip)
 			player, err := r.Player(ctx)
 			if err != nil {
 				return fmt.Errorf("error getting player status: %w", err)
 			}
 			fmt.Printf("Player state: %s\n", player.State)
-			if player.Error != "" {
+			if player.Error != "" && player.Error != "false" {
 				fmt.Printf("Error: %s\n", player.Error)
 			}
 			if player.Plugin.Name != "" {
 				fmt.Printf("Plugin: %s (ID: %s)\n", player.Plugin.Name, player.Plugin.ID)
 			}
-			if player.Position != "" {
-				fmt.Printf("Position: %s\n", player.Position)
+			if player.Duration > 0 {
+				fmt.Printf("Position: %s / %s (%.1f%%)\n", format.Duration(player.Position), format.Duration(player.Duration), player.Progress())
+			} else if player.Position > 0 {
+				fmt.Printf("Position: %s\n", format.Duration(player.Position))
+			}
+			if player.Buffering.Max > 0 && player.IsBuffering() {
+				fmt.Printf("Buffering: %.0f%%\n", player.Buffering.Percent())
+			}
+			if player.StreamSegment.Bitrate > 0 {
+				fmt.Printf("Bitrate: %s (segment %d)\n", format.Bitrate(player.StreamSegment.Bitrate), player.StreamSegment.MediaSequence)
+			}
+			if player.Format.Captions != "" {
+				fmt.Printf("Subtitles: %t\n", player.Format.SubtitlesEnabled())
 			}
 			fmt.Printf("Live: %t\n", player.Live)
 			return nil
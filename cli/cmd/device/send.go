@@ -3,11 +3,11 @@ package device
 import (
 	"context"
 	"fmt"
-	"sort"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
 	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
 	"github.com/spf13/cobra"
 )
 
@@ -22,21 +22,27 @@ func SendCmd(ch *cmdutil.Helper) *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("invalid Roku host: %w", err)
 			}
-			return runSend(ctx, ip)
+			return runSend(ctx, ch.NewDevice(ip))
 		},
 	}
 	return sendCmd
 }
 
-func runSend(ctx context.Context, ip string) error {
+func runSend(ctx context.Context, device *roku.Device) error {
 	actions := roku.AvailableActions()
-	var actionNames []string
-	for name := range actions {
-		actionNames = append(actionNames, name)
+	// Hide keys the model ignores. All keys are listed when the device
+	// can't be reached, so sending reports the connection error.
+	if caps, err := device.Capabilities(ctx); err == nil {
+		var supported []api.KeySpec
+		for _, action := range actions {
+			if caps.Supports(action.Key) {
+				supported = append(supported, action)
+			}
+		}
+		actions = supported
 	}
-	sort.Strings(actionNames)
 
-	p := tea.NewProgram(initialSendModel(actionNames, ctx, ip))
+	p := tea.NewProgram(initialSendModel(actions, ctx, device.IP))
 	m, err := p.Run()
 	if err != nil {
 		return err
@@ -47,9 +53,8 @@ func runSend(ctx context.Context, ip string) error {
 		return fmt.Errorf("unexpected model type returned from tea.Program")
 	}
 	if finalModel.selected >= 0 {
-		selectedAction := actionNames[finalModel.selected]
-		device := roku.NewDevice(ip)
-		err := device.Action(ctx, selectedAction)
+		selectedAction := actions[finalModel.selected]
+		err := device.Keypress(ctx, selectedAction.Key)
 		if err != nil {
 			return fmt.Errorf("error sending action: %w", err)
 		}
@@ -59,14 +64,14 @@ func runSend(ctx context.Context, ip string) error {
 }
 
 type sendModel struct {
-	actions  []string
+	actions  []api.KeySpec
 	cursor   int
 	selected int
 	ctx      context.Context
 	ip       string
 }
 
-func initialSendModel(actions []string, ctx context.Context, ip string) sendModel {
+func initialSendModel(actions []api.KeySpec, ctx context.Context, ip string) sendModel {
 	return sendModel{
 		actions:  actions,
 		cursor:   0,
@@ -117,7 +122,7 @@ func (m sendModel) View() string {
 		if m.cursor == i {
 			cursor = ">"
 		}
-		s += fmt.Sprintf("%s %s\n", cursor, action)
+		s += fmt.Sprintf("%s %-12s %s\n", cursor, action.Name, action.Description)
 	}
 
 	s += "\nPress q to quit, enter to send.\n"
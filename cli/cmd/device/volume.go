@@ -0,0 +1,178 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/spf13/cobra"
+)
+
+func VolumeCmd(ch *cmdutil.Helper) *cobra.Command {
+	var volumeCmd = &cobra.Command{
+		Use:   "volume",
+		Short: "Step or set the volume of a Roku TV.",
+		Long: `Step or set the volume of a Roku TV or soundbar.
+
+ECP can only step the volume, so 'roku volume set' first presses volumedown
+enough times to reach zero from any level, then volumeup to the level asked
+for. Roku TVs take 100 presses from full volume to zero. Devices that take
+fewer can be calibrated with 'roku volume calibrate' to make set faster.
+
+Examples:
+  roku volume up 5
+  roku volume set 20 --device living-room
+  roku volume calibrate 50 --device soundbar`,
+	}
+	volumeCmd.PersistentFlags().StringP("device", "d", "", "Device name or IP, the default device when empty")
+	volumeCmd.PersistentFlags().Duration("pause", roku.DefaultKeyPause, "Delay between keypresses")
+	volumeCmd.AddCommand(
+		volumeStepCmd(ch, "up", api.KeyVolumeUp),
+		volumeStepCmd(ch, "down", api.KeyVolumeDown),
+		volumeSetCmd(ch),
+		volumeMuteCmd(ch),
+		volumeCalibrateCmd(ch),
+	)
+	return volumeCmd
+}
+
+func volumeStepCmd(ch *cmdutil.Helper, name string, key api.Key) *cobra.Command {
+	return &cobra.Command{
+		Use:   name + " [steps]",
+		Short: fmt.Sprintf("Turn the volume %s, one step by default.", name),
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			steps := 1
+			if len(args) == 1 {
+				n, err := strconv.Atoi(args[0])
+				if err != nil || n < 1 || n > roku.DefaultVolumeSteps {
+					return fmt.Errorf("invalid steps %q, expected 1 to %d", args[0], roku.DefaultVolumeSteps)
+				}
+				steps = n
+			}
+			device, pause, err := volumeDevice(ch, cmd)
+			if err != nil {
+				return err
+			}
+			if err := checkSupported(cmd.Context(), device, key); err != nil {
+				return err
+			}
+			if err := device.Press(cmd.Context(), key, steps, pause); err != nil {
+				return fmt.Errorf("error changing volume: %w", err)
+			}
+			fmt.Printf("Volume %s %d\n", name, steps)
+			return nil
+		},
+	}
+}
+
+func volumeSetCmd(ch *cmdutil.Helper) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <level>",
+		Short: "Set the volume to a level by stepping down to zero and back up.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			level, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid volume %q, expected a number", args[0])
+			}
+			name, err := cmd.Flags().GetString("device")
+			if err != nil {
+				return fmt.Errorf("unable to complete (volume set) command: %w", err)
+			}
+			device, pause, err := volumeDevice(ch, cmd)
+			if err != nil {
+				return err
+			}
+			if err := checkSupported(cmd.Context(), device, api.KeyVolumeUp); err != nil {
+				return err
+			}
+			steps := ch.VolumeSteps(name)
+			presses := steps + level
+			fmt.Printf("Setting volume to %d, %d keypresses over about %s\n", level, presses, (time.Duration(presses) * pause).Round(time.Second))
+			if err := device.SetVolume(cmd.Context(), level, steps, pause); err != nil {
+				return fmt.Errorf("error setting volume: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+func volumeMuteCmd(ch *cmdutil.Helper) *cobra.Command {
+	return &cobra.Command{
+		Use:   "mute",
+		Short: "Toggle mute.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			device, _, err := volumeDevice(ch, cmd)
+			if err != nil {
+				return err
+			}
+			if err := checkSupported(cmd.Context(), device, api.KeyVolumeMute); err != nil {
+				return err
+			}
+			if err := device.Keypress(cmd.Context(), api.KeyVolumeMute); err != nil {
+				return fmt.Errorf("error toggling mute: %w", err)
+			}
+			fmt.Println("Mute toggled")
+			return nil
+		},
+	}
+}
+
+func volumeCalibrateCmd(ch *cmdutil.Helper) *cobra.Command {
+	return &cobra.Command{
+		Use:   "calibrate <steps>",
+		Short: "Store how many volumedown presses take the device from full volume to zero.",
+		Long: `Store how many volumedown presses take the device from full volume to zero.
+To find the number, turn the volume all the way up with the remote, then
+count the presses of 'roku volume down' until it reaches zero.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			steps, err := strconv.Atoi(args[0])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps %q, expected a positive number", args[0])
+			}
+			name, err := cmd.Flags().GetString("device")
+			if err != nil {
+				return fmt.Errorf("unable to complete (volume calibrate) command: %w", err)
+			}
+			if err := ch.SetVolumeSteps(name, steps); err != nil {
+				return err
+			}
+			fmt.Printf("Volume calibrated to %d steps\n", steps)
+			return nil
+		},
+	}
+}
+
+// volumeDevice returns the device and pause from the volume command flags
+func volumeDevice(ch *cmdutil.Helper, cmd *cobra.Command) (*roku.Device, time.Duration, error) {
+	name, err := cmd.Flags().GetString("device")
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to complete (volume) command: %w", err)
+	}
+	pause, err := cmd.Flags().GetDuration("pause")
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to complete (volume) command: %w", err)
+	}
+	ip, err := ch.ResolveDevice(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ch.NewDevice(ip), pause, nil
+}
+
+// checkSupported refuses keys the device model ignores. Other errors, such
+// as the device being unreachable, are left for the keypress to report.
+func checkSupported(ctx context.Context, device *roku.Device, key api.Key) error {
+	if err := device.CheckKey(ctx, key); errors.Is(err, roku.ErrUnsupported) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package device
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/spf13/cobra"
+)
+
+func WatchCmd(ch *cmdutil.Helper) *cobra.Command {
+	var watchCmd = &cobra.Command{
+		Use:   "watch",
+		Short: "Stream device state changes as JSON lines.",
+		Long: `Polls the Roku and prints a JSON object on its own line whenever the
+active app, player state or power mode changes.
+
+Player and power changes are pushed over an ECP-2 session when the device
+supports it, falling back to polling otherwise.
+
+Example:
+  roku watch --interval 1s | jq .type`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			ip, err := ch.ValidateRokuHost()
+			if err != nil {
+				return err
+			}
+			interval, err := cmd.Flags().GetDuration("interval")
+			if err != nil {
+				return fmt.Errorf("unable to complete (watch) command: %w", err)
+			}
+			powerInterval, err := cmd.Flags().GetDuration("power-interval")
+			if err != nil {
+				return fmt.Errorf("unable to complete (watch) command: %w", err)
+			}
+
+			useECP2, err := cmd.Flags().GetBool("ecp2")
+			if err != nil {
+				return fmt.Errorf("unable to complete (watch) command: %w", err)
+			}
+
+			r := ch.NewDevice(ip)
+			if useECP2 {
+				// Fall back to plain ECP polling when the session can't be opened
+				if err := r.ConnectECP2(ctx); err == nil {
+					defer r.Close()
+				}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			for event := range r.Watch(ctx, roku.WatchOptions{Interval: interval, PowerInterval: powerInterval}) {
+				if err := enc.Encode(event); err != nil {
+					return fmt.Errorf("error writing event: %w", err)
+				}
+			}
+			return nil
+		},
+	}
+	watchCmd.Flags().Duration("interval", roku.DefaultWatchInterval, "How often to poll the active app and player")
+	watchCmd.Flags().Bool("ecp2", true, "Use an ECP-2 session for pushed notifications when available")
+	watchCmd.Flags().Duration("power-interval", roku.DefaultPowerInterval, "How often to poll the power mode")
+	return watchCmd
+}
@@ -0,0 +1,32 @@
+package provision
+
+import (
+	"fmt"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
+	var deleteCmd = &cobra.Command{
+		Use:   "delete",
+		Short: "Remove the sideloaded channel.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			device, err := ch.DeveloperDevice(ctx)
+			if err != nil {
+				return err
+			}
+			result, err := device.DeleteSideload(ctx)
+			if result != nil {
+				printResult(result)
+			}
+			if err != nil {
+				return fmt.Errorf("error deleting channel: %w", err)
+			}
+			fmt.Println("Sideloaded channel deleted.")
+			return nil
+		},
+	}
+	return deleteCmd
+}
@@ -0,0 +1,33 @@
+package provision
+
+import (
+	"fmt"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/spf13/cobra"
+)
+
+func ProvisionCmd(ch *cmdutil.Helper) *cobra.Command {
+	var provisionCmd = &cobra.Command{
+		Use:   "provision",
+		Short: "Sideload, remove and rekey channels on a dev-mode Roku.",
+		Long: `Manage the sideloaded channel through the developer installer.
+
+The installer authenticates as rokudev with the password from
+roku.dev_password in the config file or ROKU_DEV_PASSWORD.`,
+	}
+	provisionCmd.AddCommand(
+		SideloadCmd(ch),
+		DeleteCmd(ch),
+		RekeyCmd(ch),
+	)
+	return provisionCmd
+}
+
+// printResult reports the installer's status messages
+func printResult(result *api.InstallerResult) {
+	for _, msg := range result.Messages {
+		fmt.Printf("Installer: %s\n", msg)
+	}
+}
@@ -0,0 +1,52 @@
+package provision
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func RekeyCmd(ch *cmdutil.Helper) *cobra.Command {
+	var rekeyCmd = &cobra.Command{
+		Use:   "rekey [signed-package]",
+		Short: "Set the device signing key from a signed package.",
+		Long: `Rekey the device with the developer ID of a previously signed package,
+so channels can be packaged with the same key on this device.
+
+Example:
+  roku provision rekey signed.pkg --password <signing password>`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			password, err := cmd.Flags().GetString("password")
+			if err != nil {
+				return fmt.Errorf("unable to complete (rekey) command: %w", err)
+			}
+			if password == "" {
+				return fmt.Errorf("you must provide the signing password with --password")
+			}
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("error reading signed package: %w", err)
+			}
+
+			device, err := ch.DeveloperDevice(ctx)
+			if err != nil {
+				return err
+			}
+			result, err := device.Rekey(ctx, data, password)
+			if result != nil {
+				printResult(result)
+			}
+			if err != nil {
+				return fmt.Errorf("error rekeying device: %w", err)
+			}
+			fmt.Println("Device rekeyed successfully.")
+			return nil
+		},
+	}
+	rekeyCmd.Flags().StringP("password", "p", "", "Password generated when the signing key was created")
+	return rekeyCmd
+}
@@ -0,0 +1,70 @@
+package provision
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku/channel"
+	"github.com/spf13/cobra"
+)
+
+func SideloadCmd(ch *cmdutil.Helper) *cobra.Command {
+	var sideloadCmd = &cobra.Command{
+		Use:   "sideload [channel-dir]",
+		Short: "Package and install a channel from a directory.",
+		Long: `Zip a BrightScript channel and install it with the developer installer,
+replacing any channel that is already sideloaded.
+
+The directory must contain a manifest. Paths listed in a .rokuignore file
+are left out of the package.
+
+Examples:
+  roku provision sideload ./channel
+  roku provision sideload ./channel --zip-only -o channel.zip`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				return fmt.Errorf("unable to complete (sideload) command: %w", err)
+			}
+			zipOnly, err := cmd.Flags().GetBool("zip-only")
+			if err != nil {
+				return fmt.Errorf("unable to complete (sideload) command: %w", err)
+			}
+
+			pkg, err := channel.Build(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Packaged %s %s (%d files, %d bytes)\n", pkg.Manifest.Title(), pkg.Manifest.Version(), len(pkg.Files), len(pkg.Data))
+			if output != "" {
+				if err := os.WriteFile(output, pkg.Data, 0o644); err != nil {
+					return fmt.Errorf("error writing package: %w", err)
+				}
+				fmt.Printf("Saved package to %s\n", output)
+			}
+			if zipOnly {
+				return nil
+			}
+
+			device, err := ch.DeveloperDevice(ctx)
+			if err != nil {
+				return err
+			}
+			result, err := device.Sideload(ctx, pkg.Data)
+			if result != nil {
+				printResult(result)
+			}
+			if err != nil {
+				return fmt.Errorf("error sideloading channel: %w", err)
+			}
+			fmt.Println("Channel sideloaded successfully.")
+			return nil
+		},
+	}
+	sideloadCmd.Flags().StringP("output", "o", "", "Also write the zip to this file")
+	sideloadCmd.Flags().Bool("zip-only", false, "Build the package without installing it")
+	return sideloadCmd
+}
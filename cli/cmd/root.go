@@ -2,12 +2,17 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"strings"
 
 	"github.com/grahamplata/roku-remote/cli/cmd/apps"
+	"github.com/grahamplata/roku-remote/cli/cmd/automation"
+	"github.com/grahamplata/roku-remote/cli/cmd/dev"
 	"github.com/grahamplata/roku-remote/cli/cmd/device"
+	"github.com/grahamplata/roku-remote/cli/cmd/provision"
+	"github.com/grahamplata/roku-remote/cli/cmd/service"
 	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -30,6 +35,8 @@ func Run(ctx context.Context, version string) {
 	}
 
 	err = RootCmd(ch, cfg, version).ExecuteContext(ctx)
+	// Save any recording even when the command failed
+	err = errors.Join(err, ch.Close())
 	code := HandleExecuteError(ch, err)
 	os.Exit(code)
 }
@@ -40,10 +47,36 @@ func RootCmd(ch *cmdutil.Helper, cfg *Config, version string) *cobra.Command {
 		Short:   "A cli tool to interact with roku devices on your local network.",
 		Long:    `Using SSDP (Simple Service Discovery Protocol) access your Roku's RESTful API`,
 		Version: version,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			record, err := cmd.Flags().GetString("record")
+			if err != nil {
+				return err
+			}
+			replay, err := cmd.Flags().GetString("replay")
+			if err != nil {
+				return err
+			}
+			if err := ch.UseCassette(record, replay); err != nil {
+				return err
+			}
+			verbose, err := cmd.Flags().GetBool("verbose")
+			if err != nil {
+				return err
+			}
+			logFormat, err := cmd.Flags().GetString("log-format")
+			if err != nil {
+				return err
+			}
+			return ch.UseLogger(verbose, logFormat)
+		},
 	}
 
 	rootCmd.PersistentFlags().StringVar(&cfg.CfgFile, "config", "", "config file (default is $HOME/.roku-remote.yaml)")
 	rootCmd.PersistentFlags().String("host", "", "host ip of the roku")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "log each ECP request to stderr")
+	rootCmd.PersistentFlags().String("log-format", "text", "log format: text or json")
+	rootCmd.PersistentFlags().String("record", "", "record ECP requests and responses to this cassette file")
+	rootCmd.PersistentFlags().String("replay", "", "answer ECP requests from this cassette file instead of the device")
 	err := viper.BindPFlag("roku.host", rootCmd.PersistentFlags().Lookup("host"))
 	if err != nil {
 		log.Printf("Error binding flags: %v", err)
@@ -57,6 +90,7 @@ func RootCmd(ch *cmdutil.Helper, cfg *Config, version string) *cobra.Command {
 		apps.AddCmd(ch),
 		apps.LaunchCmd(ch),
 		apps.ListCmd(ch),
+		apps.SearchCmd(ch),
 	)
 
 	// Device Commands
@@ -67,6 +101,32 @@ func RootCmd(ch *cmdutil.Helper, cfg *Config, version string) *cobra.Command {
 		device.LiveCmd(ch),
 		device.SendCmd(ch),
 		device.SwitchCmd(ch),
+		device.WatchCmd(ch),
+		device.VolumeCmd(ch),
+		device.InputCmd(ch),
+		device.DoctorCmd(ch),
+	)
+
+	// Developer Commands
+	cmdutil.AddGroup(rootCmd, "developer",
+		dev.DevCmd(ch),
+		provision.ProvisionCmd(ch),
+	)
+
+	// Automation Commands
+	cmdutil.AddGroup(rootCmd, "automation",
+		automation.ScheduleCmd(ch),
+		automation.DaemonCmd(ch),
+		automation.SleepCmd(ch),
+		automation.UsageCmd(ch),
+		automation.HistoryCmd(ch),
+		automation.SceneCmd(ch),
+	)
+
+	// Service Commands
+	cmdutil.AddGroup(rootCmd, "service",
+		service.ExporterCmd(ch),
+		service.MQTTCmd(ch),
 	)
 
 	return rootCmd
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku/exporter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+)
+
+// DefaultExporterAddress is the port allocated to the Roku exporter
+const DefaultExporterAddress = ":9102"
+
+func ExporterCmd(ch *cmdutil.Helper) *cobra.Command {
+	var exporterCmd = &cobra.Command{
+		Use:   "exporter",
+		Short: "Serve Prometheus metrics for the configured devices.",
+		Long: `Serve Prometheus metrics for every device in roku.devices, or the default
+device when no list is stored. Devices are queried over ECP on each scrape.
+
+Metrics include roku_up, roku_uptime_seconds, roku_power_mode,
+roku_active_app, roku_player_state, roku_device_info and the latency and
+errors of the ECP requests made (roku_ecp_request_duration_seconds and
+roku_ecp_request_errors_total).
+
+Example:
+  roku exporter --listen :9102`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			devices, err := ch.ConfiguredDevices()
+			if err != nil {
+				return err
+			}
+			listen, err := cmd.Flags().GetString("listen")
+			if err != nil {
+				return fmt.Errorf("unable to complete (exporter) command: %w", err)
+			}
+			timeout, err := cmd.Flags().GetDuration("timeout")
+			if err != nil {
+				return fmt.Errorf("unable to complete (exporter) command: %w", err)
+			}
+
+			e := exporter.New(devices, ch.Transport(), ch.Logger())
+			e.Timeout = timeout
+			registry := prometheus.NewRegistry()
+			if err := registry.Register(e); err != nil {
+				return fmt.Errorf("error registering exporter: %w", err)
+			}
+
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `<html><head><title>Roku Exporter</title></head><body><h1>Roku Exporter</h1><p><a href="/metrics">Metrics</a></p></body></html>`)
+			})
+			return serve(ctx, &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+				fmt.Sprintf("Serving metrics for %d device(s) on %s/metrics", len(devices), listen))
+		},
+	}
+	exporterCmd.Flags().String("listen", DefaultExporterAddress, "Address to serve metrics on")
+	exporterCmd.Flags().Duration("timeout", exporter.DefaultScrapeTimeout, "Maximum time to spend querying each device per scrape")
+	return exporterCmd
+}
+
+// serve runs server until ctx is cancelled, then shuts it down gracefully
+func serve(ctx context.Context, server *http.Server, banner string) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	fmt.Println(banner)
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("error serving: %w", err)
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error shutting down: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/grahamplata/roku-remote/cli/pkg/cmdutil"
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/bridge"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// mqttTimeout bounds connecting, publishing and subscribing
+const mqttTimeout = 10 * time.Second
+
+func MQTTCmd(ch *cmdutil.Helper) *cobra.Command {
+	var mqttCmd = &cobra.Command{
+		Use:   "mqtt",
+		Short: "Bridge the configured devices to an MQTT broker.",
+		Long: `Publish the state of every device in roku.devices to an MQTT broker and
+forward commands received on MQTT to the devices.
+
+For each device, identified by its serial number:
+  roku/<id>/state          power, active app and player state as JSON
+  roku/<id>/availability   online or offline
+  roku/<id>/keypress       send a key such as home or volumeup
+  roku/<id>/launch         launch an app by id
+  roku/<id>/input          send text
+
+Home Assistant discovery payloads are published so each device appears with
+sensors for its state, a button per key and a text input.
+
+The broker password can also be set with mqtt.password in the config file or
+the ROKU_MQTT_PASSWORD environment variable.
+
+Example:
+  roku mqtt --broker tcp://localhost:1883`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			ips, err := ch.ConfiguredDevices()
+			if err != nil {
+				return err
+			}
+			brokerURL, err := cmd.Flags().GetString("broker")
+			if err != nil {
+				return fmt.Errorf("unable to complete (mqtt) command: %w", err)
+			}
+			clientID, err := cmd.Flags().GetString("client-id")
+			if err != nil {
+				return fmt.Errorf("unable to complete (mqtt) command: %w", err)
+			}
+			username, err := cmd.Flags().GetString("username")
+			if err != nil {
+				return fmt.Errorf("unable to complete (mqtt) command: %w", err)
+			}
+			prefix, err := cmd.Flags().GetString("prefix")
+			if err != nil {
+				return fmt.Errorf("unable to complete (mqtt) command: %w", err)
+			}
+			discoveryPrefix, err := cmd.Flags().GetString("discovery-prefix")
+			if err != nil {
+				return fmt.Errorf("unable to complete (mqtt) command: %w", err)
+			}
+			discovery, err := cmd.Flags().GetBool("discovery")
+			if err != nil {
+				return fmt.Errorf("unable to complete (mqtt) command: %w", err)
+			}
+			interval, err := cmd.Flags().GetDuration("interval")
+			if err != nil {
+				return fmt.Errorf("unable to complete (mqtt) command: %w", err)
+			}
+
+			var devices []*roku.Device
+			for _, ip := range ips {
+				devices = append(devices, ch.NewDevice(ip))
+			}
+			broker := &pahoBroker{handlers: make(map[string]func(string, []byte))}
+			b := bridge.New(broker, devices, bridge.Options{
+				Prefix:          prefix,
+				DiscoveryPrefix: discoveryPrefix,
+				Discovery:       discovery,
+				Watch:           roku.WatchOptions{Interval: interval},
+				Logger:          ch.Logger(),
+			})
+
+			opts := mqtt.NewClientOptions().
+				AddBroker(brokerURL).
+				SetClientID(clientID).
+				SetUsername(username).
+				SetPassword(viper.GetString("mqtt.password")).
+				SetWill(b.StatusTopic(), bridge.Offline, 1, true).
+				SetAutoReconnect(true).
+				SetOnConnectHandler(func(mqtt.Client) {
+					// Subscriptions and the status are lost with the session
+					broker.resubscribe()
+					_ = broker.Publish(b.StatusTopic(), true, []byte(bridge.Online))
+				})
+			if err := broker.connect(opts); err != nil {
+				return err
+			}
+			defer broker.disconnect(b.StatusTopic())
+
+			fmt.Printf("Bridging %d device(s) to %s\n", len(devices), brokerURL)
+			return b.Run(ctx)
+		},
+	}
+	mqttCmd.Flags().String("broker", "tcp://localhost:1883", "MQTT broker URL")
+	mqttCmd.Flags().String("client-id", "roku-remote", "MQTT client id")
+	mqttCmd.Flags().String("username", "", "MQTT username")
+	mqttCmd.Flags().String("prefix", bridge.DefaultPrefix, "Topic prefix for state and commands")
+	mqttCmd.Flags().String("discovery-prefix", bridge.DefaultDiscoveryPrefix, "Home Assistant discovery prefix")
+	mqttCmd.Flags().Bool("discovery", true, "Publish Home Assistant discovery payloads")
+	mqttCmd.Flags().Duration("interval", roku.DefaultWatchInterval, "How often to poll devices for changes")
+	return mqttCmd
+}
+
+// pahoBroker adapts a paho client to bridge.Broker, restoring subscriptions
+// after a reconnect
+type pahoBroker struct {
+	client mqtt.Client
+
+	mu       sync.Mutex
+	handlers map[string]func(string, []byte)
+}
+
+func (p *pahoBroker) connect(opts *mqtt.ClientOptions) error {
+	p.client = mqtt.NewClient(opts)
+	token := p.client.Connect()
+	if !token.WaitTimeout(mqttTimeout) {
+		return fmt.Errorf("timed out connecting to MQTT broker")
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("error connecting to MQTT broker: %w", err)
+	}
+	return nil
+}
+
+// disconnect marks the bridge offline and closes the connection
+func (p *pahoBroker) disconnect(statusTopic string) {
+	_ = p.Publish(statusTopic, true, []byte(bridge.Offline))
+	p.client.Disconnect(250)
+}
+
+func (p *pahoBroker) Publish(topic string, retain bool, payload []byte) error {
+	return wait(p.client.Publish(topic, 1, retain, payload))
+}
+
+func (p *pahoBroker) Subscribe(topic string, handler func(string, []byte)) error {
+	p.mu.Lock()
+	p.handlers[topic] = handler
+	p.mu.Unlock()
+	return p.subscribe(topic, handler)
+}
+
+func (p *pahoBroker) subscribe(topic string, handler func(string, []byte)) error {
+	return wait(p.client.Subscribe(topic, 1, func(_ mqtt.Client, msg mqtt.Message) {
+		handler(msg.Topic(), msg.Payload())
+	}))
+}
+
+func (p *pahoBroker) resubscribe() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for topic, handler := range p.handlers {
+		// Called from paho's connect callback, so don't block on the token
+		p.client.Subscribe(topic, 1, func(_ mqtt.Client, msg mqtt.Message) {
+			handler(msg.Topic(), msg.Payload())
+		})
+	}
+}
+
+// wait blocks until token completes or mqttTimeout passes
+func wait(token mqtt.Token) error {
+	if !token.WaitTimeout(mqttTimeout) {
+		return fmt.Errorf("timed out waiting for MQTT broker")
+	}
+	return token.Error()
+}
@@ -1,16 +1,39 @@
 package cmdutil
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/grahamplata/roku-remote/roku/scene"
+	"github.com/grahamplata/roku-remote/roku/schedule"
+	"github.com/grahamplata/roku-remote/roku/usage"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-type Helper struct{}
+type Helper struct {
+	// replaying is set when ECP responses come from a cassette rather than the network
+	replaying bool
+	// configErr is why the config file couldn't be read, if it couldn't
+	configErr error
+	// logger traces ECP requests and discovery, set by UseLogger
+	logger *slog.Logger
+	// recorder is saved by Close when --record is used
+	recorder *api.Recorder
+	// transport carries ECP requests, set by UseCassette. nil uses the network.
+	transport http.RoundTripper
+}
 
 func NewHelper() (*Helper, error) {
 	ch := &Helper{}
@@ -26,9 +49,16 @@ func NewHelper() (*Helper, error) {
 	viper.SetConfigName(".roku-remote")
 
 	viper.AutomaticEnv()
+	if err := viper.BindEnv("roku.dev_password", "ROKU_DEV_PASSWORD"); err != nil {
+		return nil, fmt.Errorf("error binding environment: %w", err)
+	}
+	if err := viper.BindEnv("mqtt.password", "ROKU_MQTT_PASSWORD"); err != nil {
+		return nil, fmt.Errorf("error binding environment: %w", err)
+	}
 
 	if err := viper.ReadInConfig(); err != nil {
-		fmt.Printf("Config file not found or readable: %v\n", err)
+		ch.configErr = err
+		fmt.Fprintf(os.Stderr, "Config file not found or readable: %v\n", err)
 	}
 
 	return ch, nil
@@ -55,13 +85,387 @@ func (h *Helper) ValidateRokuHost() (string, error) {
 		return "", fmt.Errorf("invalid host IP address: %s", ip)
 	}
 
+	// Recorded responses stand in for the device, which may not be reachable
+	if h.replaying {
+		return ip, nil
+	}
+
 	// Test basic connectivity to Roku device on port 8060
 	address := fmt.Sprintf("%s:8060", ip)
 	conn, err := net.DialTimeout("tcp", address, 3*time.Second)
 	if err != nil {
-		return "", fmt.Errorf("unable to connect to Roku device at %s: %w\n\nPlease ensure:\n  • The Roku device is powered on\n  • The device is connected to the same network\n  • The IP address is correct (run 'roku-remote device find' to re-scan)", ip, err)
+		return "", fmt.Errorf("unable to connect to Roku device at %s: %w. Run 'roku doctor' to diagnose the connection", ip, err)
 	}
 	conn.Close()
 
 	return ip, nil
 }
+
+// ConfiguredDevices returns the IPs stored in roku.devices, falling back to
+// roku.host when no list has been saved
+func (h *Helper) ConfiguredDevices() ([]string, error) {
+	devices := viper.GetStringSlice("roku.devices")
+	if len(devices) == 0 {
+		if host := viper.GetString("roku.host"); host != "" {
+			devices = []string{host}
+		}
+	}
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("no Roku device configured. Run 'roku find' command first to set a default device")
+	}
+	for _, ip := range devices {
+		if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("invalid host IP address: %s", ip)
+		}
+	}
+	return devices, nil
+}
+
+// ResolveDevice returns the IP for a device given by name or IP. Names are
+// looked up in the roku.names map of the config file, and an empty name is
+// the default device.
+func (h *Helper) ResolveDevice(name string) (string, error) {
+	if name == "" {
+		name = viper.GetString("roku.host")
+		if name == "" {
+			return "", fmt.Errorf("no Roku device configured. Run 'roku find' command first to set a default device")
+		}
+	}
+	if ip := viper.GetStringMapString("roku.names")[strings.ToLower(name)]; ip != "" {
+		name = ip
+	}
+	if net.ParseIP(name) == nil {
+		return "", fmt.Errorf("unknown device %q. Use an IP address or add a name under roku.names in the config file", name)
+	}
+	return name, nil
+}
+
+// ValidateConfig checks the settings in the config file, returning the file
+// in use and a description of each problem found
+func (h *Helper) ValidateConfig() (string, []string) {
+	var problems []string
+	if h.configErr != nil {
+		problems = append(problems, h.configErr.Error())
+	}
+	if host := viper.GetString("roku.host"); host == "" {
+		problems = append(problems, "roku.host is not set, run 'roku find'")
+	} else if net.ParseIP(host) == nil {
+		problems = append(problems, fmt.Sprintf("roku.host %q is not an IP address", host))
+	}
+	for _, ip := range viper.GetStringSlice("roku.devices") {
+		if net.ParseIP(ip) == nil {
+			problems = append(problems, fmt.Sprintf("roku.devices entry %q is not an IP address", ip))
+		}
+	}
+	for name, ip := range viper.GetStringMapString("roku.names") {
+		if net.ParseIP(ip) == nil {
+			problems = append(problems, fmt.Sprintf("roku.names %s is %q, not an IP address", name, ip))
+		}
+	}
+	var calibrations []volumeCalibration
+	if err := viper.UnmarshalKey("roku.volume_steps", &calibrations); err != nil {
+		problems = append(problems, fmt.Sprintf("roku.volume_steps: %v", err))
+	}
+	for _, c := range calibrations {
+		if _, err := h.ResolveDevice(c.Device); err != nil || c.Steps < 1 {
+			problems = append(problems, fmt.Sprintf("roku.volume_steps entry for %q needs a known device and positive steps", c.Device))
+		}
+	}
+	scenes, err := h.Scenes()
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	for name, s := range scenes {
+		for _, part := range s.Parts {
+			if _, err := h.ResolveDevice(part.Device); err != nil {
+				problems = append(problems, fmt.Sprintf("scene %s device %q is not an IP or a name in roku.names", name, part.Device))
+			}
+		}
+	}
+	if _, err := h.ScheduleRules(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := h.Limits(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	sort.Strings(problems)
+	return viper.ConfigFileUsed(), problems
+}
+
+// ScheduleEntry is how a rule is stored under the schedule config key
+type ScheduleEntry struct {
+	Spec    string `mapstructure:"spec" yaml:"spec"`
+	Created string `mapstructure:"created" yaml:"created"`
+}
+
+// ScheduleEntries reads the stored rules from the config
+func (h *Helper) ScheduleEntries() ([]ScheduleEntry, error) {
+	var entries []ScheduleEntry
+	if err := viper.UnmarshalKey("schedule", &entries); err != nil {
+		return nil, fmt.Errorf("invalid schedule in config file: %w", err)
+	}
+	return entries, nil
+}
+
+// SaveScheduleEntries replaces the stored rules and writes the config file
+func (h *Helper) SaveScheduleEntries(entries []ScheduleEntry) error {
+	viper.Set("schedule", entries)
+	return h.WriteConfig()
+}
+
+// ScheduleRules parses the stored rules
+func (h *Helper) ScheduleRules() ([]schedule.Rule, error) {
+	entries, err := h.ScheduleEntries()
+	if err != nil {
+		return nil, err
+	}
+	var rules []schedule.Rule
+	for _, entry := range entries {
+		rule, err := schedule.Parse(entry.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q in config file: %w", entry.Spec, err)
+		}
+		rule.Created, err = time.Parse(time.RFC3339, entry.Created)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created time for rule %q in config file: %w", entry.Spec, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// limitEntry is how a limit is stored under the limits config key
+type limitEntry struct {
+	Device string `mapstructure:"device"`
+	App    string `mapstructure:"app"`
+	Daily  string `mapstructure:"daily"`
+}
+
+// Limits reads the limits config key, resolving device names
+func (h *Helper) Limits() ([]usage.Limit, error) {
+	var entries []limitEntry
+	if err := viper.UnmarshalKey("limits", &entries); err != nil {
+		return nil, fmt.Errorf("invalid limits in config file: %w", err)
+	}
+	var limits []usage.Limit
+	for _, entry := range entries {
+		daily, err := time.ParseDuration(entry.Daily)
+		if err != nil || daily <= 0 {
+			return nil, fmt.Errorf("invalid daily limit %q in config file, expected a value such as 2h or 45m", entry.Daily)
+		}
+		limit := usage.Limit{App: strings.TrimSpace(entry.App), Daily: daily}
+		if entry.Device != "" {
+			if limit.Device, err = h.ResolveDevice(entry.Device); err != nil {
+				return nil, err
+			}
+		}
+		limits = append(limits, limit)
+	}
+	return limits, nil
+}
+
+// Scenes parses the scenes in the config file
+func (h *Helper) Scenes() (map[string]scene.Scene, error) {
+	var raw map[string][]scene.Entry
+	if err := viper.UnmarshalKey("scenes", &raw); err != nil {
+		return nil, fmt.Errorf("invalid scenes in config file: %w", err)
+	}
+	scenes := make(map[string]scene.Scene, len(raw))
+	for name, entries := range raw {
+		s, err := scene.Parse(name, entries)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scene in config file: %w", err)
+		}
+		scenes[name] = s
+	}
+	return scenes, nil
+}
+
+// volumeCalibration is how a device's volume steps are stored under
+// roku.volume_steps. IPs can't be map keys in viper as they contain dots.
+type volumeCalibration struct {
+	Device string `mapstructure:"device" yaml:"device"`
+	Steps  int    `mapstructure:"steps" yaml:"steps"`
+}
+
+// VolumeSteps returns the calibrated number of volumedown presses from full
+// volume to zero for a device given by name or IP, set under
+// roku.volume_steps in the config file, or roku.DefaultVolumeSteps
+func (h *Helper) VolumeSteps(name string) int {
+	ip, err := h.ResolveDevice(name)
+	if err != nil {
+		return roku.DefaultVolumeSteps
+	}
+	for _, c := range h.volumeCalibrations() {
+		if other, err := h.ResolveDevice(c.Device); err == nil && other == ip && c.Steps > 0 {
+			return c.Steps
+		}
+	}
+	return roku.DefaultVolumeSteps
+}
+
+// SetVolumeSteps stores the volume calibration for a device name or IP, or
+// the default device when name is empty
+func (h *Helper) SetVolumeSteps(name string, steps int) error {
+	ip, err := h.ResolveDevice(name)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = ip
+	}
+	var calibrations []volumeCalibration
+	for _, c := range h.volumeCalibrations() {
+		if other, err := h.ResolveDevice(c.Device); err != nil || other != ip {
+			calibrations = append(calibrations, c)
+		}
+	}
+	calibrations = append(calibrations, volumeCalibration{Device: strings.ToLower(name), Steps: steps})
+	viper.Set("roku.volume_steps", calibrations)
+	return h.WriteConfig()
+}
+
+func (h *Helper) volumeCalibrations() []volumeCalibration {
+	var calibrations []volumeCalibration
+	// An invalid entry is ignored so volume keys keep working
+	_ = viper.UnmarshalKey("roku.volume_steps", &calibrations)
+	return calibrations
+}
+
+// DataDir returns the directory for state and logs kept by long running
+// commands, creating it if needed
+func (h *Helper) DataDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding config directory: %w", err)
+	}
+	dir = filepath.Join(dir, "roku-remote")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("error creating data directory: %w", err)
+	}
+	return dir, nil
+}
+
+// WriteConfig saves the current settings to the config file in use, or
+// $HOME/.roku-remote.yaml when none was loaded
+func (h *Helper) WriteConfig() error {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return fmt.Errorf("error finding home directory: %w", err)
+		}
+		path = filepath.Join(home, ".roku-remote.yaml")
+	}
+	if err := viper.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+	return nil
+}
+
+// UseCassette routes ECP requests through a recorder writing to record or a
+// replayer serving responses from replay. Both empty leaves the network as is.
+func (h *Helper) UseCassette(record, replay string) error {
+	switch {
+	case record != "" && replay != "":
+		return fmt.Errorf("--record and --replay cannot be used together")
+	case record != "":
+		h.recorder = api.NewRecorder(record, nil)
+		h.transport = h.recorder
+	case replay != "":
+		cassette, err := api.LoadCassette(replay)
+		if err != nil {
+			return err
+		}
+		replayer, err := api.NewReplayer(cassette)
+		if err != nil {
+			return err
+		}
+		h.transport = replayer
+		h.replaying = true
+	}
+	return nil
+}
+
+// Close saves the cassette being recorded, if any
+func (h *Helper) Close() error {
+	if h.recorder == nil {
+		return nil
+	}
+	return h.recorder.Close()
+}
+
+// UseLogger configures logging of ECP requests and discovery to stderr. Only
+// warnings are shown unless verbose is set. logFormat is text or json.
+func (h *Helper) UseLogger(verbose bool, logFormat string) error {
+	level := slog.LevelWarn
+	if verbose {
+		level = slog.LevelDebug
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch logFormat {
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q, expected text or json", logFormat)
+	}
+	h.logger = slog.New(handler)
+	return nil
+}
+
+// Logger returns the logger set by UseLogger, which discards output until
+// UseLogger is called
+func (h *Helper) Logger() *slog.Logger {
+	if h.logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return h.logger
+}
+
+// Transport returns the transport set by UseCassette for ECP requests, nil
+// when requests go to the network
+func (h *Helper) Transport() http.RoundTripper {
+	return h.transport
+}
+
+// NewDevice creates a device at ip that traces its requests to Logger and
+// sends them through Transport
+func (h *Helper) NewDevice(ip string) *roku.Device {
+	return roku.NewDevice(ip, roku.WithLogger(h.logger), roku.WithTransport(h.transport))
+}
+
+// DevPassword returns the developer installer password from the roku.dev_password
+// config key or the ROKU_DEV_PASSWORD environment variable
+func (h *Helper) DevPassword() (string, error) {
+	password := viper.GetString("roku.dev_password")
+	if password == "" {
+		return "", fmt.Errorf("no developer password configured. Set roku.dev_password in the config file or the ROKU_DEV_PASSWORD environment variable")
+	}
+	return password, nil
+}
+
+// DeveloperDevice validates the configured host, checks developer mode is
+// enabled and returns a device with installer access configured
+func (h *Helper) DeveloperDevice(ctx context.Context) (*roku.Device, error) {
+	ip, err := h.ValidateRokuHost()
+	if err != nil {
+		return nil, err
+	}
+	password, err := h.DevPassword()
+	if err != nil {
+		return nil, err
+	}
+	device := h.NewDevice(ip)
+	info, err := device.DeviceInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting device info: %w", err)
+	}
+	if !info.DeveloperEnabled {
+		return nil, fmt.Errorf("developer mode is not enabled on %s. Press Home 3x, Up 2x, Right, Left, Right, Left, Right on the remote to enable it", ip)
+	}
+	device.EnableDeveloper(password)
+	return device, nil
+}
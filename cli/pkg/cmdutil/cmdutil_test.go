@@ -1,8 +1,11 @@
 package cmdutil
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/grahamplata/roku-remote/roku"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -121,3 +124,118 @@ func TestNewHelper(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, helper)
 }
+
+func TestDevPassword(t *testing.T) {
+	viper.Reset()
+	helper := &Helper{}
+
+	_, err := helper.DevPassword()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ROKU_DEV_PASSWORD")
+
+	viper.Set("roku.dev_password", "secret")
+	password, err := helper.DevPassword()
+	require.NoError(t, err)
+	assert.Equal(t, "secret", password)
+}
+
+func TestResolveDevice(t *testing.T) {
+	viper.Reset()
+	viper.Set("roku.host", "192.168.1.100")
+	viper.Set("roku.names", map[string]string{"kids": "192.168.1.101"})
+	ch := &Helper{}
+
+	ip, err := ch.ResolveDevice("")
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.1.100", ip)
+
+	ip, err = ch.ResolveDevice("Kids")
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.1.101", ip)
+
+	ip, err = ch.ResolveDevice("192.168.1.102")
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.1.102", ip)
+
+	_, err = ch.ResolveDevice("bedroom")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "roku.names")
+}
+
+func TestVolumeSteps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	viper.Reset()
+	viper.SetConfigFile(path)
+	viper.Set("roku.host", "192.168.1.100")
+	viper.Set("roku.names", map[string]string{"kids": "192.168.1.101"})
+	ch := &Helper{}
+
+	assert.Equal(t, roku.DefaultVolumeSteps, ch.VolumeSteps(""))
+
+	require.NoError(t, ch.SetVolumeSteps("", 40))
+	require.NoError(t, ch.SetVolumeSteps("Kids", 30))
+
+	// Reload so IP keys are read back from the file
+	viper.Reset()
+	viper.SetConfigFile(path)
+	require.NoError(t, viper.ReadInConfig())
+
+	assert.Equal(t, 40, ch.VolumeSteps(""))
+	assert.Equal(t, 40, ch.VolumeSteps("192.168.1.100"))
+	assert.Equal(t, 30, ch.VolumeSteps("kids"))
+	assert.Equal(t, roku.DefaultVolumeSteps, ch.VolumeSteps("192.168.1.102"))
+}
+
+func TestValidateConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`roku:
+  host: 192.168.1.100
+  names:
+    kids: 192.168.1.101
+  volume_steps:
+    - device: kids
+      steps: 30
+scenes:
+  bedtime:
+    - device: kids
+      steps: [poweroff]
+    - device: 192.168.1.5
+      steps: [poweroff]
+schedule:
+  - spec: "22:30 poweroff --device kids"
+    created: "2026-03-01T12:00:00Z"
+limits:
+  - device: kids
+    daily: 2h
+`), 0o644))
+	viper.Reset()
+	viper.SetConfigFile(path)
+	require.NoError(t, viper.ReadInConfig())
+	ch := &Helper{}
+
+	used, problems := ch.ValidateConfig()
+	assert.Equal(t, path, used)
+	assert.Empty(t, problems)
+
+	// Writing the config keeps scene devices given by IP intact
+	require.NoError(t, ch.WriteConfig())
+	viper.Reset()
+	viper.SetConfigFile(path)
+	require.NoError(t, viper.ReadInConfig())
+	_, problems = ch.ValidateConfig()
+	assert.Empty(t, problems)
+
+	viper.Set("roku.names", map[string]string{"kids": "kids-tv"})
+	viper.Set("scenes", map[string]any{"bedtime": []map[string]any{{"device": "kids", "steps": []string{"dance"}}}})
+	viper.Set("limits", []map[string]string{{"daily": "forever"}})
+	_, problems = ch.ValidateConfig()
+	assert.Len(t, problems, 4)
+	assert.Contains(t, problems, `roku.names kids is "kids-tv", not an IP address`)
+	assert.Contains(t, problems, `invalid daily limit "forever" in config file, expected a value such as 2h or 45m`)
+
+	viper.Set("scenes", nil)
+	viper.Set("limits", nil)
+	viper.Set("schedule", []map[string]string{{"spec": "22:30 poweroff"}})
+	_, problems = ch.ValidateConfig()
+	assert.Contains(t, problems, `invalid created time for rule "22:30 poweroff" in config file: parsing time "" as "2006-01-02T15:04:05Z07:00": cannot parse "" as "2006"`)
+}
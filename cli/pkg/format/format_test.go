@@ -0,0 +1,34 @@
+package format
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuration(t *testing.T) {
+	assert.Equal(t, "0:00", Duration(0))
+	assert.Equal(t, "4:05", Duration(4*time.Minute+5*time.Second+300*time.Millisecond))
+	assert.Equal(t, "1:02:03", Duration(time.Hour+2*time.Minute+3*time.Second))
+	assert.Equal(t, "0:00", Duration(-time.Second))
+}
+
+func TestBitrate(t *testing.T) {
+	assert.Equal(t, "512 bps", Bitrate(512))
+	assert.Equal(t, "128.0 Kbps", Bitrate(128000))
+	assert.Equal(t, "2.2 Mbps", Bitrate(2208000))
+}
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▄█", Sparkline([]float64{0, 50, 100}, 10))
+	assert.Equal(t, "▄█", Sparkline([]float64{0, 50, 100}, 2))
+	assert.Equal(t, "▁▁", Sparkline([]float64{0, 0}, 0))
+	assert.Equal(t, "", Sparkline(nil, 5))
+}
+
+func TestBytes(t *testing.T) {
+	assert.Equal(t, "512 B", Bytes(512))
+	assert.Equal(t, "1.5 KiB", Bytes(1536))
+	assert.Equal(t, "20.0 MiB", Bytes(20*1024*1024))
+}
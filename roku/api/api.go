@@ -7,24 +7,107 @@ https://developer.roku.com/docs/developer-program/debugging/external-control-api
 */
 
 import (
+	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
-	EndpointRoot        = "/"
-	EndpointApps        = "/query/apps"
-	EndpointDeviceInfo  = "/query/device-info"
-	EndpointActiveApp   = "/query/active-app"
-	EndpointMediaPlayer = "/query/media-player"
-	EndpointIcon        = "/query/icon/"
-	EndpointInput       = "/input"
-	EndpointSearch      = "/search"
-	EndpointKeypress    = "/keypress"
-	EndpointKeydown     = "/keydown"
-	EndpointLaunch      = "/launch"
-	EndpointInstall     = "/install"
+	EndpointRoot         = "/"
+	EndpointApps         = "/query/apps"
+	EndpointDeviceInfo   = "/query/device-info"
+	EndpointActiveApp    = "/query/active-app"
+	EndpointMediaPlayer  = "/query/media-player"
+	EndpointIcon         = "/query/icon/"
+	EndpointInput        = "/input"
+	EndpointSearch       = "/search/browse"
+	EndpointKeypress     = "/keypress"
+	EndpointKeydown      = "/keydown"
+	EndpointLaunch       = "/launch"
+	EndpointInstall      = "/install"
+	EndpointSGNodesAll   = "/query/sgnodes/all"
+	EndpointSGNodesRoots = "/query/sgnodes/roots"
+	EndpointSGNodesNodes = "/query/sgnodes/nodes"
+	EndpointChanPerf     = "/query/chanperf"
+	EndpointRegistry     = "/query/registry/"
+	EndpointBitmaps      = "/query/r2d2-bitmaps"
+	EndpointFrameRate    = "/query/graphics-frame-rate"
 )
 
+// SearchTypes are the content types accepted by SearchOptions.Type
+var SearchTypes = []string{"movie", "tv-show", "person", "channel", "game"}
+
+// SearchOptions are the parameters of /search/browse, which opens the
+// search results for a title on the device. Keyword or Title is required.
+type SearchOptions struct {
+	// Keyword matches titles containing it
+	Keyword string
+	// Title matches a title exactly
+	Title string
+	// Type is one of SearchTypes
+	Type string
+	// Season selects a season of a tv-show, starting at 1
+	Season int
+	// TMSID is the Gracenote id of the content
+	TMSID string
+	// ProviderIDs are channel ids to prefer, in order
+	ProviderIDs []string
+	// Launch starts playback in the first provider that has the content
+	// instead of showing the results. It needs a provider.
+	Launch bool
+	// MatchAny picks the first match when several titles match
+	MatchAny bool
+}
+
+// Values returns the options as /search/browse query parameters
+func (o SearchOptions) Values() (url.Values, error) {
+	if o.Keyword == "" && o.Title == "" {
+		return nil, errors.New("search needs a keyword or title")
+	}
+	if o.Type != "" && !slices.Contains(SearchTypes, o.Type) {
+		return nil, fmt.Errorf("invalid search type %q, expected one of %s", o.Type, strings.Join(SearchTypes, ", "))
+	}
+	if o.Season < 0 {
+		return nil, fmt.Errorf("invalid season %d", o.Season)
+	}
+	if o.Launch && len(o.ProviderIDs) == 0 {
+		return nil, errors.New("launching a search result needs a provider")
+	}
+	v := url.Values{}
+	if o.Keyword != "" {
+		v.Set("keyword", o.Keyword)
+	}
+	if o.Title != "" {
+		v.Set("title", o.Title)
+	}
+	if o.Type != "" {
+		v.Set("type", o.Type)
+	}
+	if o.Season > 0 {
+		v.Set("season", strconv.Itoa(o.Season))
+	}
+	if o.TMSID != "" {
+		v.Set("tmsid", o.TMSID)
+	}
+	if len(o.ProviderIDs) > 0 {
+		v.Set("provider-id", strings.Join(o.ProviderIDs, ","))
+	}
+	if o.Launch {
+		v.Set("launch", "true")
+	}
+	if o.MatchAny {
+		v.Set("match-any", "true")
+	}
+	return v, nil
+}
+
 // Info type encapsulates the roku device info at the root endpoint
 type Info struct {
 	XMLName xml.Name    `xml:"root" json:"-"`
@@ -103,6 +186,7 @@ type DeviceInfo struct {
 	HeadphonesConnected      bool     `xml:"headphones-connected" json:"headphones_connected,omitempty"`
 	SupportsEcsTextedit      bool     `xml:"supports-ecs-textedit" json:"supports_ecs_textedit,omitempty"`
 	SupportsEcsMicrophone    bool     `xml:"supports-ecs-microphone" json:"supports_ecs_microphone,omitempty"`
+	EcpSettingMode           string   `xml:"ecp-setting-mode" json:"ecp_setting_mode,omitempty"`
 	SupportsWakeOnWlan       string   `xml:"supports-wake-on-wlan" json:"supports_wake_on_wlan,omitempty"`
 	HasPlayOnRoku            string   `xml:"has-play-on-roku" json:"has_play_on_roku,omitempty"`
 	HasMobileScreensaver     string   `xml:"has-mobile-screensaver" json:"has_mobile_screensaver,omitempty"`
@@ -129,12 +213,94 @@ type App struct {
 
 // Player type of roku media player
 type Player struct {
-	Error    string `xml:"error,attr" json:"error"`
-	State    string `xml:"state,attr" json:"state"`
-	Plugin   plugin `xml:"plugin" json:"plugin"`
-	Format   format `xml:"format" json:"format"`
-	Position string `xml:"position" json:"position"`
-	Live     bool   `xml:"is_live" json:"live"`
+	Error         string        `json:"error"`
+	State         string        `json:"state"`
+	Plugin        plugin        `json:"plugin"`
+	Format        format        `json:"format"`
+	Buffering     buffering     `json:"buffering"`
+	NewStream     newStream     `json:"new_stream"`
+	StreamSegment streamSegment `json:"stream_segment"`
+	Position      time.Duration `json:"position"`
+	Duration      time.Duration `json:"duration"`
+	Runtime       time.Duration `json:"runtime"`
+	Live          bool          `json:"live"`
+}
+
+// playerXML mirrors the raw /query/media-player document before the
+// millisecond strings are converted into durations
+type playerXML struct {
+	Error         string        `xml:"error,attr"`
+	State         string        `xml:"state,attr"`
+	Plugin        plugin        `xml:"plugin"`
+	Format        format        `xml:"format"`
+	Buffering     buffering     `xml:"buffering"`
+	NewStream     newStream     `xml:"new_stream"`
+	StreamSegment streamSegment `xml:"stream_segment"`
+	Position      string        `xml:"position"`
+	Duration      string        `xml:"duration"`
+	Runtime       string        `xml:"runtime"`
+	Live          bool          `xml:"is_live"`
+}
+
+// UnmarshalXML decodes a media player document, parsing values such as
+// "12345 ms" into time.Duration fields
+func (p *Player) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var raw playerXML
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+	position, err := parseMilliseconds(raw.Position)
+	if err != nil {
+		return fmt.Errorf("invalid position: %w", err)
+	}
+	duration, err := parseMilliseconds(raw.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+	runtime, err := parseMilliseconds(raw.Runtime)
+	if err != nil {
+		return fmt.Errorf("invalid runtime: %w", err)
+	}
+	*p = Player{
+		Error:         raw.Error,
+		State:         raw.State,
+		Plugin:        raw.Plugin,
+		Format:        raw.Format,
+		Buffering:     raw.Buffering,
+		NewStream:     raw.NewStream,
+		StreamSegment: raw.StreamSegment,
+		Position:      position,
+		Duration:      duration,
+		Runtime:       runtime,
+		Live:          raw.Live,
+	}
+	return nil
+}
+
+// MarshalJSON writes position, duration and runtime in the form the device
+// reports them, such as "12345 ms", rather than as nanoseconds
+func (p Player) MarshalJSON() ([]byte, error) {
+	type player Player
+	return json.Marshal(struct {
+		player
+		Position string `json:"position"`
+		Duration string `json:"duration"`
+		Runtime  string `json:"runtime"`
+	}{player(p), formatMilliseconds(p.Position), formatMilliseconds(p.Duration), formatMilliseconds(p.Runtime)})
+}
+
+// Progress returns the playback position as a percentage of the duration,
+// or 0 when the duration is unknown (e.g. live streams)
+func (p *Player) Progress() float64 {
+	if p.Duration <= 0 {
+		return 0
+	}
+	return float64(p.Position) / float64(p.Duration) * 100
+}
+
+// IsBuffering reports whether the player is waiting on its buffer to fill
+func (p *Player) IsBuffering() bool {
+	return p.State == "buffer"
 }
 
 // ActiveApp represents the currently active application on the Roku device
@@ -142,6 +308,280 @@ type ActiveApp struct {
 	App App `xml:"app" json:"app"`
 }
 
+// SGNodes is a SceneGraph node tree from one of the /query/sgnodes endpoints
+type SGNodes struct {
+	Nodes  []SGNode `json:"nodes"`
+	Status string   `json:"status"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// sgnodesContainers are elements in sgnodes responses that wrap the actual
+// nodes rather than being nodes themselves
+var sgnodesContainers = map[string]bool{"sgnodes": true, "All_Nodes": true, "Root_Nodes": true, "Nodes": true}
+
+// UnmarshalXML unwraps the sgnodes document into its top level nodes, keeping
+// the status and error elements that accompany them
+func (s *SGNodes) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if !sgnodesContainers[start.Name.Local] {
+		var root SGNode
+		if err := root.UnmarshalXML(d, start); err != nil {
+			return err
+		}
+		s.Nodes = []SGNode{root}
+		return nil
+	}
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "status":
+				err = d.DecodeElement(&s.Status, &t)
+			case "error":
+				err = d.DecodeElement(&s.Error, &t)
+			default:
+				var child SGNode
+				if err = child.UnmarshalXML(d, t); err == nil {
+					s.Nodes = append(s.Nodes, unwrapSGNodes(child)...)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// unwrapSGNodes returns the nodes inside any container elements
+func unwrapSGNodes(node SGNode) []SGNode {
+	if !sgnodesContainers[node.Type] {
+		return []SGNode{node}
+	}
+	var nodes []SGNode
+	for _, child := range node.Children {
+		nodes = append(nodes, unwrapSGNodes(child)...)
+	}
+	return nodes
+}
+
+// SGNode is a single SceneGraph node. The element name is the node type and
+// its fields are kept as attributes.
+type SGNode struct {
+	Type       string            `json:"type"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Children   []SGNode          `json:"children,omitempty"`
+}
+
+// UnmarshalXML decodes a node element and its children recursively
+func (n *SGNode) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	n.Type = start.Name.Local
+	if len(start.Attr) > 0 {
+		n.Attributes = make(map[string]string, len(start.Attr))
+		for _, attr := range start.Attr {
+			n.Attributes[attr.Name.Local] = attr.Value
+		}
+	}
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			var child SGNode
+			if err := child.UnmarshalXML(d, t); err != nil {
+				return err
+			}
+			n.Children = append(n.Children, child)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// ID returns the node id, which sgnodes reports as the name attribute
+func (n *SGNode) ID() string {
+	if id := n.Attributes["id"]; id != "" {
+		return id
+	}
+	return n.Attributes["name"]
+}
+
+// Focused reports whether the node currently has focus
+func (n *SGNode) Focused() bool {
+	return n.Attributes["focused"] == "true"
+}
+
+// RefCount returns the node's reference count, or -1 if it isn't reported
+func (n *SGNode) RefCount() int {
+	rc, err := strconv.Atoi(n.Attributes["rc"])
+	if err != nil {
+		return -1
+	}
+	return rc
+}
+
+// Walk calls fn for the node and each descendant in depth first order,
+// skipping a node's children when fn returns false
+func (n *SGNode) Walk(fn func(node *SGNode, depth int) bool) {
+	n.walk(fn, 0)
+}
+
+func (n *SGNode) walk(fn func(node *SGNode, depth int) bool, depth int) {
+	if !fn(n, depth) {
+		return
+	}
+	for i := range n.Children {
+		n.Children[i].walk(fn, depth+1)
+	}
+}
+
+// Find returns every node in the tree for which match returns true
+func (s *SGNodes) Find(match func(node *SGNode) bool) []*SGNode {
+	var found []*SGNode
+	for i := range s.Nodes {
+		s.Nodes[i].Walk(func(node *SGNode, depth int) bool {
+			if match(node) {
+				found = append(found, node)
+			}
+			return true
+		})
+	}
+	return found
+}
+
+// Count returns the total number of nodes in the tree
+func (s *SGNodes) Count() int {
+	return len(s.Find(func(*SGNode) bool { return true }))
+}
+
+// ChannelPerf is a CPU and memory sample for a channel from /query/chanperf
+type ChannelPerf struct {
+	Plugin chanPerfPlugin `xml:"plugin" json:"plugin"`
+	Status string         `xml:"status" json:"status"`
+	Error  string         `xml:"error" json:"error,omitempty"`
+}
+
+// CPUPercent returns the combined user and system CPU usage
+func (p *ChannelPerf) CPUPercent() float64 {
+	return p.Plugin.CPU.User + p.Plugin.CPU.Sys
+}
+
+type chanPerfPlugin struct {
+	ID     string      `xml:"id,attr" json:"id"`
+	CPU    cpuPercent  `xml:"cpu-percent" json:"cpu_percent"`
+	Memory memoryUsage `xml:"memory" json:"memory"`
+}
+
+type cpuPercent struct {
+	DurationSeconds int     `xml:"duration-seconds" json:"duration_seconds"`
+	User            float64 `xml:"user" json:"user"`
+	Sys             float64 `xml:"sys" json:"sys"`
+}
+
+// memoryUsage values are in bytes
+type memoryUsage struct {
+	Used   int64 `xml:"used" json:"used"`
+	Res    int64 `xml:"res" json:"res"`
+	Anon   int64 `xml:"anon" json:"anon"`
+	File   int64 `xml:"file" json:"file"`
+	Shared int64 `xml:"shared" json:"shared"`
+	Swap   int64 `xml:"swap" json:"swap"`
+}
+
+// Registry is a channel's persistent registry from /query/registry
+type Registry struct {
+	DevID          string            `xml:"registry>dev-id" json:"dev_id"`
+	PluginID       string            `xml:"registry>plugin-id" json:"plugin_id"`
+	SpaceAvailable int               `xml:"registry>space-available" json:"space_available"`
+	Sections       []RegistrySection `xml:"registry>sections>section" json:"sections"`
+	Status         string            `xml:"status" json:"status"`
+	Error          string            `xml:"error" json:"error,omitempty"`
+}
+
+// RegistrySection is a named group of registry keys
+type RegistrySection struct {
+	Name  string         `xml:"name" json:"name"`
+	Items []RegistryItem `xml:"items>item" json:"items"`
+}
+
+// RegistryItem is a single key and value in a registry section
+type RegistryItem struct {
+	Key   string `xml:"key" json:"key"`
+	Value string `xml:"value" json:"value"`
+}
+
+// Section returns the named section, or nil if it doesn't exist
+func (r *Registry) Section(name string) *RegistrySection {
+	for i := range r.Sections {
+		if r.Sections[i].Name == name {
+			return &r.Sections[i]
+		}
+	}
+	return nil
+}
+
+// Get returns the value of key in section and whether it was found
+func (r *Registry) Get(section, key string) (string, bool) {
+	s := r.Section(section)
+	if s == nil {
+		return "", false
+	}
+	for _, item := range s.Items {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return "", false
+}
+
+// Bitmaps lists the texture allocations of a channel from /query/r2d2-bitmaps
+type Bitmaps struct {
+	Sizes   bitmapSizes `xml:"sizes" json:"sizes"`
+	Bitmaps []Bitmap    `xml:"bitmaps>bitmap" json:"bitmaps"`
+	Status  string      `xml:"status" json:"status"`
+	Error   string      `xml:"error" json:"error,omitempty"`
+}
+
+// Bitmap is a single texture allocation
+type Bitmap struct {
+	Name   string `xml:"name,attr" json:"name"`
+	Width  int    `xml:"w,attr" json:"width"`
+	Height int    `xml:"h,attr" json:"height"`
+	Size   int64  `xml:"size,attr" json:"size"`
+}
+
+// Top returns the n largest bitmaps by size, largest first
+func (b *Bitmaps) Top(n int) []Bitmap {
+	top := append([]Bitmap{}, b.Bitmaps...)
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].Size > top[j].Size
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// bitmapSizes values are in bytes
+type bitmapSizes struct {
+	Available int64 `xml:"available" json:"available"`
+	Max       int64 `xml:"max" json:"max"`
+	Used      int64 `xml:"used" json:"used"`
+}
+
+// FrameRate is the current graphics frame rate from /query/graphics-frame-rate
+type FrameRate struct {
+	FPS    float64 `xml:"frame-rate" json:"fps"`
+	Status string  `xml:"status" json:"status"`
+	Error  string  `xml:"error" json:"error,omitempty"`
+}
+
 type specVersion struct {
 	Major int `xml:"major" json:"major"`
 	Minor int `xml:"minor" json:"minor"`
@@ -166,8 +606,63 @@ type plugin struct {
 }
 
 type format struct {
-	Audio    string `xml:"audio,attr" json:"audio"`
-	Video    string `xml:"video,attr" json:"video"`
-	Captions string `xml:"captions,attr" json:"captions"`
-	DRM      string `xml:"drm,attr" json:"drm"`
+	Audio     string `xml:"audio,attr" json:"audio"`
+	Video     string `xml:"video,attr" json:"video"`
+	Captions  string `xml:"captions,attr" json:"captions"`
+	DRM       string `xml:"drm,attr" json:"drm"`
+	Container string `xml:"container,attr" json:"container,omitempty"`
+	VideoRes  string `xml:"vidRes,attr" json:"video_resolution,omitempty"`
+}
+
+// SubtitlesEnabled reports whether captions or subtitles are being rendered
+func (f format) SubtitlesEnabled() bool {
+	return f.Captions != "" && !strings.EqualFold(f.Captions, "none")
+}
+
+type buffering struct {
+	Current int `xml:"current,attr" json:"current"`
+	Max     int `xml:"max,attr" json:"max"`
+	Target  int `xml:"target,attr" json:"target"`
+}
+
+// Percent returns how full the buffer is as a percentage
+func (b buffering) Percent() float64 {
+	if b.Max <= 0 {
+		return 0
+	}
+	return float64(b.Current) / float64(b.Max) * 100
+}
+
+type newStream struct {
+	Speed string `xml:"speed,attr" json:"speed"`
+}
+
+type streamSegment struct {
+	Bitrate       int    `xml:"bitrate,attr" json:"bitrate"`
+	MediaSequence int    `xml:"media_sequence,attr" json:"media_sequence"`
+	SegmentType   string `xml:"segment_type,attr" json:"segment_type"`
+	Time          int64  `xml:"time,attr" json:"time"`
+}
+
+// parseMilliseconds converts an ECP time value such as "12345 ms" into a
+// duration. Bare numbers are treated as milliseconds.
+// formatMilliseconds is the inverse of parseMilliseconds, giving an empty
+// string for zero as the device omits the value
+func formatMilliseconds(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d ms", d.Milliseconds())
+}
+
+func parseMilliseconds(value string) (time.Duration, error) {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "ms"))
+	if value == "" {
+		return 0, nil
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
 }
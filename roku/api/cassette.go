@@ -0,0 +1,225 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cassette is a recording of ECP traffic that can be replayed offline
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request and the response the device sent
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the part of a request used to match it during replay
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response as returned by the device
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// LoadCassette reads a cassette file written by a Recorder
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to path as indented JSON. It is written to a
+// temporary file first and renamed into place, so a crash part way through
+// leaves any previous cassette intact.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Recorder is an http.RoundTripper that records every request and response
+// in memory. Close writes them to the cassette file.
+type Recorder struct {
+	path string
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates a recorder writing to path. Requests are sent with base,
+// or http.DefaultTransport if it is nil.
+func NewRecorder(path string, base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{path: path, base: base}
+}
+
+// RoundTrip sends the request and records it along with the response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: RecordedRequest{Method: req.Method, URL: req.URL.String(), Body: reqBody},
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: resp.Header.Clone(),
+			Body:   string(respBody),
+		},
+	})
+	return resp, nil
+}
+
+// Close saves the interactions recorded so far to the cassette file. It can
+// be called more than once, each call rewriting the file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// Replayer is an http.RoundTripper that serves responses from a cassette
+// instead of the network. Requests are matched on method, path, query and
+// body but not host, so a recording from one device can be replayed against
+// any IP. Repeated requests are answered with successive recordings in order,
+// and the last one is reused once they run out, which suits polling loops.
+type Replayer struct {
+	mu     sync.Mutex
+	queues map[string][]RecordedResponse
+}
+
+// NewReplayer creates a replayer serving the interactions in cassette
+func NewReplayer(cassette *Cassette) (*Replayer, error) {
+	r := &Replayer{queues: make(map[string][]RecordedResponse)}
+	for _, interaction := range cassette.Interactions {
+		key, err := replayKey(interaction.Request.Method, interaction.Request.URL, interaction.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		r.queues[key] = append(r.queues[key], interaction.Response)
+	}
+	return r, nil
+}
+
+// RoundTrip returns the next recorded response matching req
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	key, err := replayKey(req.Method, req.URL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	queue := r.queues[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL.RequestURI())
+	}
+	recorded := queue[0]
+	if len(queue) > 1 {
+		r.queues[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// replayKey identifies a request independent of the device it was sent to
+func replayKey(method, rawURL, body string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid recorded request %s %s: %w", method, rawURL, err)
+	}
+	return method + " " + u.RequestURI() + "\n" + body, nil
+}
+
+// readRequestBody consumes the request body and returns it along with a copy
+// of the request whose body can still be sent by the next transport
+func readRequestBody(req *http.Request) (*http.Request, string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, "", nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read request body: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return req, string(body), nil
+}
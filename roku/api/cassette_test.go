@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_Replayer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	// Record against a mock device whose active app changes between polls
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case EndpointActiveApp:
+			polls++
+			w.WriteHeader(http.StatusOK)
+			if polls == 1 {
+				fmt.Fprint(w, `<active-app><app>Roku</app></active-app>`)
+			} else {
+				fmt.Fprint(w, `<active-app><app id="12">Netflix</app></active-app>`)
+			}
+		case EndpointLaunch:
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "id=12", string(body))
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	recorder := NewRecorder(path, &customTransport{testServerURL: server.URL, base: http.DefaultTransport})
+	client := NewClient("192.168.1.10", &http.Client{Transport: recorder})
+
+	ctx := context.Background()
+	_, err := client.ActiveApp(ctx)
+	require.NoError(t, err)
+	require.NoError(t, client.Launch(ctx, "12"))
+	_, err = client.ActiveApp(ctx)
+	require.NoError(t, err)
+	server.Close()
+	require.NoError(t, recorder.Close())
+
+	cassette, err := LoadCassette(path)
+	require.NoError(t, err)
+	require.Len(t, cassette.Interactions, 3)
+	assert.Equal(t, http.MethodPost, cassette.Interactions[1].Request.Method)
+
+	// Replay offline against a different IP
+	replayer, err := NewReplayer(cassette)
+	require.NoError(t, err)
+	client = NewClient("10.0.0.5", &http.Client{Transport: replayer})
+
+	app, err := client.ActiveApp(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Roku", app.App.Name)
+	require.NoError(t, client.Launch(ctx, "12"))
+	app, err = client.ActiveApp(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "12", app.App.ID)
+
+	// The last recording is reused once the queue runs out
+	app, err = client.ActiveApp(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "12", app.App.ID)
+
+	// Requests that were never recorded fail without touching the network
+	_, err = client.DeviceInfo(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no recorded response")
+}
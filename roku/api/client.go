@@ -3,10 +3,13 @@ package api
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"math"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -35,12 +38,27 @@ func (e *DeviceError) Unwrap() error {
 	return e.Err
 }
 
+// StatusError is returned when the device answers a request with a non-2xx
+// status
+type StatusError struct {
+	IP       string
+	Endpoint string
+	Status   int
+	Body     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d from %s%s: %s", e.Status, e.IP, e.Endpoint, e.Body)
+}
+
 // Client is an HTTP client used to communicate with the Roku device
 type Client struct {
 	// IP address of the Roku device
 	ip string `yaml:"ip"`
 	// Client is an HTTP client used to communicate with the Roku device
 	client *http.Client `yaml:"-"`
+	// logger records each request and retry, discarded by default
+	logger *slog.Logger `yaml:"-"`
 }
 
 // NewClient creates a new API client with the provided HTTP client
@@ -51,7 +69,16 @@ func NewClient(ip string, client *http.Client) *Client {
 	return &Client{
 		ip:     ip,
 		client: client,
+		logger: slog.New(slog.DiscardHandler),
+	}
+}
+
+// SetLogger sets the logger used to trace requests. A nil logger discards output.
+func (c *Client) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
 	}
+	c.logger = logger.With("device", c.ip)
 }
 
 // retryWithBackoff executes a function with exponential backoff retry logic
@@ -71,15 +98,22 @@ func (c *Client) retryWithBackoff(ctx context.Context, operation func() error) e
 		}
 
 		// Don't retry on certain errors (non-transient)
-		if strings.Contains(lastErr.Error(), "Limited mode") ||
-			strings.Contains(lastErr.Error(), "unexpected status 404") ||
-			strings.Contains(lastErr.Error(), "unexpected status 400") {
+		var limited *LimitedModeError
+		var status *StatusError
+		if errors.As(lastErr, &limited) {
 			return lastErr
 		}
+		if errors.As(lastErr, &status) {
+			switch status.Status {
+			case http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound:
+				return lastErr
+			}
+		}
 
 		// Don't sleep on last attempt
 		if attempt < MaxRetries-1 {
 			delay := InitialRetryDelay * time.Duration(math.Pow(2, float64(attempt)))
+			c.logger.DebugContext(ctx, "retrying ecp request", "attempt", attempt+1, "delay", delay, "error", lastErr)
 			select {
 			case <-time.After(delay):
 			case <-ctx.Done():
@@ -150,6 +184,122 @@ func (c *Client) MediaPlayer(ctx context.Context) (*Player, error) {
 	return &player, nil
 }
 
+// SGNodesAll retrieves every SceneGraph node in the running dev channel
+func (c *Client) SGNodesAll(ctx context.Context) (*SGNodes, error) {
+	return c.sgnodes(ctx, EndpointSGNodesAll)
+}
+
+// SGNodesRoots retrieves the root SceneGraph nodes of the running dev channel
+func (c *Client) SGNodesRoots(ctx context.Context) (*SGNodes, error) {
+	return c.sgnodes(ctx, EndpointSGNodesRoots)
+}
+
+// SGNode retrieves the SceneGraph node with the given id and its children
+func (c *Client) SGNode(ctx context.Context, nodeID string) (*SGNodes, error) {
+	if nodeID == "" {
+		return nil, fmt.Errorf("nodeID cannot be empty for device %s", c.ip)
+	}
+	return c.sgnodes(ctx, EndpointSGNodesNodes+"?node-id="+url.QueryEscape(nodeID))
+}
+
+func (c *Client) sgnodes(ctx context.Context, endpoint string) (*SGNodes, error) {
+	var nodes SGNodes
+	err := c.retryWithBackoff(ctx, func() error {
+		return c.getAndDecode(ctx, endpoint, &nodes)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sgnodes: %w", err)
+	}
+	if err := c.queryStatusError("sgnodes", nodes.Status, nodes.Error); err != nil {
+		return nil, err
+	}
+	return &nodes, nil
+}
+
+// ChannelPerf samples the CPU and memory usage of a channel. An empty appID
+// samples the channel currently running; sideloaded channels use "dev".
+func (c *Client) ChannelPerf(ctx context.Context, appID string) (*ChannelPerf, error) {
+	endpoint := EndpointChanPerf
+	if appID != "" {
+		endpoint += "/" + url.PathEscape(appID)
+	}
+	var perf ChannelPerf
+	err := c.retryWithBackoff(ctx, func() error {
+		return c.getAndDecode(ctx, endpoint, &perf)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel performance: %w", err)
+	}
+	if err := c.queryStatusError("chanperf", perf.Status, perf.Error); err != nil {
+		return nil, err
+	}
+	return &perf, nil
+}
+
+// Registry retrieves the persistent registry of a channel. Only sideloaded
+// ("dev") channels or channels on a device keyed with the same developer ID
+// can be read.
+func (c *Client) Registry(ctx context.Context, appID string) (*Registry, error) {
+	if appID == "" {
+		return nil, fmt.Errorf("appID cannot be empty for device %s", c.ip)
+	}
+	var registry Registry
+	err := c.retryWithBackoff(ctx, func() error {
+		return c.getAndDecode(ctx, EndpointRegistry+url.PathEscape(appID), &registry)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry: %w", err)
+	}
+	if err := c.queryStatusError("registry", registry.Status, registry.Error); err != nil {
+		return nil, err
+	}
+	return &registry, nil
+}
+
+// Bitmaps retrieves the texture allocations of a channel. An empty appID
+// queries the channel currently running.
+func (c *Client) Bitmaps(ctx context.Context, appID string) (*Bitmaps, error) {
+	endpoint := EndpointBitmaps
+	if appID != "" {
+		endpoint += "/" + url.PathEscape(appID)
+	}
+	var bitmaps Bitmaps
+	err := c.retryWithBackoff(ctx, func() error {
+		return c.getAndDecode(ctx, endpoint, &bitmaps)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bitmaps: %w", err)
+	}
+	if err := c.queryStatusError("r2d2-bitmaps", bitmaps.Status, bitmaps.Error); err != nil {
+		return nil, err
+	}
+	return &bitmaps, nil
+}
+
+// FrameRate retrieves the current graphics frame rate
+func (c *Client) FrameRate(ctx context.Context) (*FrameRate, error) {
+	var frameRate FrameRate
+	err := c.retryWithBackoff(ctx, func() error {
+		return c.getAndDecode(ctx, EndpointFrameRate, &frameRate)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get frame rate: %w", err)
+	}
+	if err := c.queryStatusError("graphics-frame-rate", frameRate.Status, frameRate.Error); err != nil {
+		return nil, err
+	}
+	return &frameRate, nil
+}
+
+// queryStatusError converts the status element of developer queries into an
+// error. These endpoints answer 200 OK and report failures in the body.
+func (c *Client) queryStatusError(op, status, message string) error {
+	if status == "" || status == "OK" {
+		return nil
+	}
+	return &DeviceError{Op: op, IP: c.ip, Message: fmt.Sprintf("status %s: %s", status, message)}
+}
+
 // Input sends text input to the Roku device
 func (c *Client) Input(ctx context.Context, text string) error {
 	return c.retryWithBackoff(ctx, func() error {
@@ -157,32 +307,35 @@ func (c *Client) Input(ctx context.Context, text string) error {
 	})
 }
 
-// Search performs a search on the Roku device
-func (c *Client) Search(ctx context.Context, keyword string) error {
+// Search opens the search results for the options on the Roku device, or
+// starts playback when opts.Launch is set
+func (c *Client) Search(ctx context.Context, opts SearchOptions) error {
+	values, err := opts.Values()
+	if err != nil {
+		return err
+	}
 	return c.retryWithBackoff(ctx, func() error {
-		return c.post(ctx, EndpointSearch, fmt.Sprintf("keyword=%s", keyword))
+		return c.post(ctx, EndpointSearch+"?"+values.Encode(), "")
 	})
 }
 
 // Keypress sends a keypress event to the Roku device
-func (c *Client) Keypress(ctx context.Context, action string) error {
-	val, ok := ExternalControlActions[action]
-	if !ok {
-		return fmt.Errorf("invalid action '%s' for device %s", action, c.ip)
+func (c *Client) Keypress(ctx context.Context, key Key) error {
+	if !key.Valid() {
+		return fmt.Errorf("invalid action '%s' for device %s", key, c.ip)
 	}
 	return c.retryWithBackoff(ctx, func() error {
-		return c.post(ctx, EndpointKeypress+val, "")
+		return c.post(ctx, EndpointKeypress+"/"+string(key), "")
 	})
 }
 
 // Keydown sends a keydown event to the Roku device (key held down)
-func (c *Client) Keydown(ctx context.Context, action string) error {
-	val, ok := ExternalControlActions[action]
-	if !ok {
-		return fmt.Errorf("invalid action '%s' for device %s", action, c.ip)
+func (c *Client) Keydown(ctx context.Context, key Key) error {
+	if !key.Valid() {
+		return fmt.Errorf("invalid action '%s' for device %s", key, c.ip)
 	}
 	return c.retryWithBackoff(ctx, func() error {
-		return c.post(ctx, EndpointKeydown+val, "")
+		return c.post(ctx, EndpointKeydown+"/"+string(key), "")
 	})
 }
 
@@ -213,22 +366,18 @@ func (c *Client) getAndDecode(ctx context.Context, endpoint string, target inter
 	if err != nil {
 		return fmt.Errorf("failed to create request for %s%s: %w", c.ip, endpoint, err)
 	}
-	resp, err := c.client.Do(req)
+	body, status, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to perform request to %s%s: %w", c.ip, endpoint, err)
 	}
-	defer resp.Body.Close()
 	// Accept all 2xx status codes as success
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		bodyStr := string(body)
-
-		if resp.StatusCode == http.StatusForbidden && strings.Contains(bodyStr, "Limited mode") {
-			return fmt.Errorf("roku device is in Limited mode - some commands are restricted. Try pressing the Home button 5 times quickly on your Roku remote to exit Limited mode, or use 'roku-remote active-app' to see the current app")
+	if status < 200 || status >= 300 {
+		if err := c.limitedModeError(req, status, body); err != nil {
+			return err
 		}
-		return fmt.Errorf("unexpected status %d from %s%s: %s", resp.StatusCode, c.ip, endpoint, bodyStr)
+		return &StatusError{IP: c.ip, Endpoint: endpoint, Status: status, Body: string(body)}
 	}
-	return xml.NewDecoder(resp.Body).Decode(target)
+	return xml.Unmarshal(body, target)
 }
 
 func (c *Client) post(ctx context.Context, endpoint string, data string) error {
@@ -238,16 +387,37 @@ func (c *Client) post(ctx context.Context, endpoint string, data string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create request for %s%s: %w", c.ip, endpoint, err)
 	}
-	resp, err := c.client.Do(req)
+	body, status, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to perform request to %s%s: %w", c.ip, endpoint, err)
 	}
-	defer resp.Body.Close()
 	// Accept all 2xx status codes as success
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		bodyStr := string(body)
-		return fmt.Errorf("unexpected status %d from %s%s: %s", resp.StatusCode, c.ip, endpoint, bodyStr)
+	if status < 200 || status >= 300 {
+		if err := c.limitedModeError(req, status, body); err != nil {
+			return err
+		}
+		return &StatusError{IP: c.ip, Endpoint: endpoint, Status: status, Body: string(body)}
 	}
 	return nil
 }
+
+// do sends req and reads the whole response body, logging the method, URL,
+// status, latency and body size
+func (c *Client) do(req *http.Request) ([]byte, int, error) {
+	target := req.URL.String()
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.logger.DebugContext(req.Context(), "ecp request failed", "method", req.Method, "url", target,
+			"latency", time.Since(start), "error", err)
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	c.logger.DebugContext(req.Context(), "ecp request", "method", req.Method, "url", target,
+		"status", resp.StatusCode, "latency", time.Since(start), "bytes", len(body))
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, resp.StatusCode, nil
+}
@@ -1,10 +1,14 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -79,9 +83,14 @@ func mockXMLResponse(endpoint string) string {
 		EndpointMediaPlayer: `<?xml version="1.0" encoding="UTF-8"?>
 <player error="" state="play">
 	<plugin id="12" bandwidth="1000" name="Netflix"/>
-	<format audio="aac" video="h264"/>
-	<position>12345</position>
+	<format audio="aac" video="h264" captions="none" container="hls" vidRes="1920x1080"/>
+	<buffering current="400" max="1000" target="0"/>
+	<new_stream speed="128000 bps"/>
+	<position>12345 ms</position>
+	<duration>60000 ms</duration>
 	<is_live>false</is_live>
+	<runtime>60000 ms</runtime>
+	<stream_segment bitrate="2208000" media_sequence="54" segment_type="mux" time="12000"/>
 </player>`,
 	}
 	return responses[endpoint]
@@ -255,8 +264,11 @@ func TestClient_ActiveApp(t *testing.T) {
 
 		assert.Error(t, err)
 		assert.Nil(t, activeApp)
+		var limited *LimitedModeError
+		require.ErrorAs(t, err, &limited)
+		assert.Equal(t, LimitedModeError{IP: "127.0.0.1", Method: "GET", Endpoint: EndpointActiveApp}, *limited)
 		assert.Contains(t, err.Error(), "Limited mode")
-		assert.Contains(t, err.Error(), "Home button 5 times")
+		assert.Contains(t, err.Error(), "Control by mobile apps")
 	})
 }
 
@@ -277,8 +289,57 @@ func TestClient_MediaPlayer(t *testing.T) {
 		assert.Equal(t, "play", player.State)
 		assert.Equal(t, "12", player.Plugin.ID)
 		assert.Equal(t, "Netflix", player.Plugin.Name)
-		assert.Equal(t, "12345", player.Position)
+		assert.Equal(t, 12345*time.Millisecond, player.Position)
+		assert.Equal(t, time.Minute, player.Duration)
+		assert.Equal(t, time.Minute, player.Runtime)
+		assert.InDelta(t, 20.575, player.Progress(), 0.001)
+		assert.Equal(t, 400, player.Buffering.Current)
+		assert.InDelta(t, 40.0, player.Buffering.Percent(), 0.001)
+		assert.Equal(t, "128000 bps", player.NewStream.Speed)
+		assert.Equal(t, 2208000, player.StreamSegment.Bitrate)
+		assert.Equal(t, 54, player.StreamSegment.MediaSequence)
+		assert.Equal(t, "1920x1080", player.Format.VideoRes)
+		assert.False(t, player.Format.SubtitlesEnabled())
 		assert.False(t, player.Live)
+
+		// JSON keeps the millisecond strings reported by the device
+		data, err := json.Marshal(player)
+		require.NoError(t, err)
+		var out map[string]any
+		require.NoError(t, json.Unmarshal(data, &out))
+		assert.Equal(t, "12345 ms", out["position"])
+		assert.Equal(t, "60000 ms", out["duration"])
+		assert.Equal(t, "60000 ms", out["runtime"])
+		assert.Equal(t, "play", out["state"])
+	})
+
+	t.Run("NoDuration", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `<player error="false" state="close"><position>1500</position></player>`)
+		})
+		defer server.Close()
+
+		player, err := client.MediaPlayer(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1500*time.Millisecond, player.Position)
+		assert.Zero(t, player.Duration)
+		assert.Zero(t, player.Progress())
+	})
+
+	t.Run("InvalidPosition", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `<player state="play"><position>soon</position></player>`)
+		})
+		defer server.Close()
+
+		player, err := client.MediaPlayer(context.Background())
+
+		assert.Error(t, err)
+		assert.Nil(t, player)
+		assert.Contains(t, err.Error(), "invalid position")
 	})
 
 	t.Run("HTTPError", func(t *testing.T) {
@@ -318,6 +379,9 @@ func TestClient_Input(t *testing.T) {
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "unexpected status 400")
+		var status *StatusError
+		require.ErrorAs(t, err, &status)
+		assert.Equal(t, http.StatusBadRequest, status.Status)
 	})
 }
 
@@ -326,26 +390,69 @@ func TestClient_Search(t *testing.T) {
 		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
 			assert.Equal(t, "POST", r.Method)
 			assert.Equal(t, EndpointSearch, r.URL.Path)
+			assert.Equal(t, "action movies", r.URL.Query().Get("keyword"))
 			w.WriteHeader(http.StatusOK)
 		})
 		defer server.Close()
 
-		err := client.Search(context.Background(), "action movies")
+		err := client.Search(context.Background(), SearchOptions{Keyword: "action movies"})
 
 		assert.NoError(t, err)
 	})
+
+	t.Run("AllOptions", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, url.Values{
+				"title":       {"The Office"},
+				"type":        {"tv-show"},
+				"season":      {"3"},
+				"tmsid":       {"SH007404200000"},
+				"provider-id": {"12,13"},
+				"launch":      {"true"},
+				"match-any":   {"true"},
+			}, r.URL.Query())
+			w.WriteHeader(http.StatusOK)
+		})
+		defer server.Close()
+
+		err := client.Search(context.Background(), SearchOptions{
+			Title:       "The Office",
+			Type:        "tv-show",
+			Season:      3,
+			TMSID:       "SH007404200000",
+			ProviderIDs: []string{"12", "13"},
+			Launch:      true,
+			MatchAny:    true,
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("InvalidOptions", func(t *testing.T) {
+		for _, tt := range []struct {
+			opts     SearchOptions
+			errorMsg string
+		}{
+			{SearchOptions{}, "needs a keyword or title"},
+			{SearchOptions{Keyword: "office", Type: "show"}, "invalid search type"},
+			{SearchOptions{Keyword: "office", Launch: true}, "needs a provider"},
+		} {
+			_, err := tt.opts.Values()
+			assert.ErrorContains(t, err, tt.errorMsg)
+		}
+	})
 }
 
 func TestClient_Keypress(t *testing.T) {
 	tests := []struct {
 		name        string
-		action      string
+		action      Key
 		shouldError bool
 		errorMsg    string
 	}{
-		{"ValidAction_Home", "home", false, ""},
-		{"ValidAction_Select", "select", false, ""},
-		{"ValidAction_VolumeUp", "volumeup", false, ""},
+		{"ValidAction_Home", KeyHome, false, ""},
+		{"ValidAction_Select", KeySelect, false, ""},
+		{"ValidAction_VolumeUp", KeyVolumeUp, false, ""},
 		{"InvalidAction", "invalid_action", true, "invalid action"},
 	}
 
@@ -361,7 +468,7 @@ func TestClient_Keypress(t *testing.T) {
 			} else {
 				server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
 					assert.Equal(t, "POST", r.Method)
-					assert.True(t, strings.HasPrefix(r.URL.Path, EndpointKeypress))
+					assert.Equal(t, EndpointKeypress+"/"+string(tt.action), r.URL.Path)
 					w.WriteHeader(http.StatusOK)
 				})
 				defer server.Close()
@@ -374,15 +481,33 @@ func TestClient_Keypress(t *testing.T) {
 	}
 }
 
+func TestClient_KeypressLimitedMode(t *testing.T) {
+	requests := 0
+	server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "ECP command not allowed in Limited mode.")
+	})
+	defer server.Close()
+
+	err := client.Keypress(context.Background(), KeyHome)
+
+	var limited *LimitedModeError
+	require.ErrorAs(t, err, &limited)
+	assert.Equal(t, "POST", limited.Method)
+	assert.Equal(t, EndpointKeypress+"/Home", limited.Endpoint)
+	assert.Equal(t, 1, requests, "Limited mode errors are not retried")
+}
+
 func TestClient_Keydown(t *testing.T) {
 	tests := []struct {
 		name        string
-		action      string
+		action      Key
 		shouldError bool
 		errorMsg    string
 	}{
-		{"ValidAction_Up", "up", false, ""},
-		{"ValidAction_Down", "down", false, ""},
+		{"ValidAction_Up", KeyUp, false, ""},
+		{"ValidAction_Down", KeyDown, false, ""},
 		{"InvalidAction", "bad_action", true, "invalid action"},
 	}
 
@@ -498,3 +623,295 @@ func TestClient_ContextCancellation(t *testing.T) {
 		assert.Contains(t, err.Error(), "context canceled")
 	})
 }
+
+func TestClient_SGNodes(t *testing.T) {
+	const allNodes = `<?xml version="1.0" encoding="UTF-8" ?>
+<sgnodes>
+	<All_Nodes>
+		<MainScene name="" rc="2" focused="false">
+			<RowList name="homeGrid" rc="1" focused="true" itemSize="[1280,200]"/>
+			<Group name="overlay" rc="1">
+				<Label name="title" rc="1" text="Hello"/>
+			</Group>
+		</MainScene>
+	</All_Nodes>
+	<status>OK</status>
+</sgnodes>`
+
+	t.Run("All", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, EndpointSGNodesAll, r.URL.Path)
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, allNodes)
+		})
+		defer server.Close()
+
+		nodes, err := client.SGNodesAll(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, nodes.Nodes, 1)
+		scene := nodes.Nodes[0]
+		assert.Equal(t, "MainScene", scene.Type)
+		assert.Equal(t, 2, scene.RefCount())
+		require.Len(t, scene.Children, 2)
+		assert.Equal(t, "homeGrid", scene.Children[0].ID())
+		assert.True(t, scene.Children[0].Focused())
+		assert.Equal(t, "[1280,200]", scene.Children[0].Attributes["itemSize"])
+		assert.Equal(t, 4, nodes.Count())
+
+		labels := nodes.Find(func(n *SGNode) bool { return n.Type == "Label" })
+		require.Len(t, labels, 1)
+		assert.Equal(t, "Hello", labels[0].Attributes["text"])
+	})
+
+	t.Run("ByID", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, EndpointSGNodesNodes, r.URL.Path)
+			assert.Equal(t, "home grid", r.URL.Query().Get("node-id"))
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `<sgnodes><Nodes><RowList name="home grid" rc="1"/></Nodes><status>OK</status></sgnodes>`)
+		})
+		defer server.Close()
+
+		nodes, err := client.SGNode(context.Background(), "home grid")
+
+		require.NoError(t, err)
+		require.Len(t, nodes.Nodes, 1)
+		assert.Equal(t, "RowList", nodes.Nodes[0].Type)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `<sgnodes><status>FAILED</status><error>No dev channel is running</error></sgnodes>`)
+		})
+		defer server.Close()
+
+		nodes, err := client.SGNodesRoots(context.Background())
+
+		assert.Error(t, err)
+		assert.Nil(t, nodes)
+		assert.Contains(t, err.Error(), "No dev channel is running")
+	})
+
+	t.Run("EmptyNodeID", func(t *testing.T) {
+		client := NewClient("192.168.1.1", nil)
+
+		nodes, err := client.SGNode(context.Background(), "")
+
+		assert.Error(t, err)
+		assert.Nil(t, nodes)
+	})
+}
+
+func TestClient_ChannelPerf(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, EndpointChanPerf+"/dev", r.URL.Path)
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8" ?>
+<chanperf>
+	<plugin id="dev">
+		<cpu-percent>
+			<duration-seconds>1</duration-seconds>
+			<user>12.5</user>
+			<sys>2.5</sys>
+		</cpu-percent>
+		<memory>
+			<used>52428800</used>
+			<res>41943040</res>
+			<anon>31457280</anon>
+			<file>10485760</file>
+			<shared>1048576</shared>
+			<swap>0</swap>
+		</memory>
+	</plugin>
+	<status>OK</status>
+</chanperf>`)
+		})
+		defer server.Close()
+
+		perf, err := client.ChannelPerf(context.Background(), "dev")
+
+		require.NoError(t, err)
+		assert.Equal(t, "dev", perf.Plugin.ID)
+		assert.InDelta(t, 15.0, perf.CPUPercent(), 0.001)
+		assert.Equal(t, int64(52428800), perf.Plugin.Memory.Used)
+		assert.Equal(t, int64(41943040), perf.Plugin.Memory.Res)
+	})
+
+	t.Run("NoChannelRunning", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, EndpointChanPerf, r.URL.Path)
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `<chanperf><status>FAILED</status><error>Channel not running</error></chanperf>`)
+		})
+		defer server.Close()
+
+		perf, err := client.ChannelPerf(context.Background(), "")
+
+		assert.Error(t, err)
+		assert.Nil(t, perf)
+		assert.Contains(t, err.Error(), "Channel not running")
+	})
+}
+
+func TestClient_Registry(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, EndpointRegistry+"dev", r.URL.Path)
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8" ?>
+<plugin-registry>
+	<registry>
+		<dev-id>abc123</dev-id>
+		<plugin-id>dev</plugin-id>
+		<space-available>15840</space-available>
+		<sections>
+			<section>
+				<name>auth</name>
+				<items>
+					<item><key>token</key><value>secret-token</value></item>
+					<item><key>user</key><value>graham</value></item>
+				</items>
+			</section>
+			<section>
+				<name>prefs</name>
+				<items>
+					<item><key>captions</key><value>on</value></item>
+				</items>
+			</section>
+		</sections>
+	</registry>
+	<status>OK</status>
+</plugin-registry>`)
+		})
+		defer server.Close()
+
+		registry, err := client.Registry(context.Background(), "dev")
+
+		require.NoError(t, err)
+		assert.Equal(t, "dev", registry.PluginID)
+		assert.Equal(t, 15840, registry.SpaceAvailable)
+		require.Len(t, registry.Sections, 2)
+		assert.Len(t, registry.Section("auth").Items, 2)
+		assert.Nil(t, registry.Section("missing"))
+
+		value, ok := registry.Get("auth", "token")
+		assert.True(t, ok)
+		assert.Equal(t, "secret-token", value)
+		_, ok = registry.Get("prefs", "token")
+		assert.False(t, ok)
+	})
+
+	t.Run("NotPermitted", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `<plugin-registry><status>FAILED</status><error>Plugin 12 not found</error></plugin-registry>`)
+		})
+		defer server.Close()
+
+		registry, err := client.Registry(context.Background(), "12")
+
+		assert.Error(t, err)
+		assert.Nil(t, registry)
+		assert.Contains(t, err.Error(), "Plugin 12 not found")
+	})
+
+	t.Run("EmptyAppID", func(t *testing.T) {
+		client := NewClient("192.168.1.1", nil)
+
+		registry, err := client.Registry(context.Background(), "")
+
+		assert.Error(t, err)
+		assert.Nil(t, registry)
+	})
+}
+
+func TestClient_Bitmaps(t *testing.T) {
+	server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, EndpointBitmaps+"/dev", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8" ?>
+<r2d2-bitmaps>
+	<sizes>
+		<available>98947072</available>
+		<max>272629760</max>
+		<used>173682688</used>
+	</sizes>
+	<bitmaps>
+		<bitmap name="pkg:/images/icon.png" w="336" h="210" size="282240"/>
+		<bitmap name="pkg:/images/background.jpg" w="1920" h="1080" size="8294400"/>
+		<bitmap name="font cache" w="1024" h="1024" size="1048576"/>
+	</bitmaps>
+	<status>OK</status>
+</r2d2-bitmaps>`)
+	})
+	defer server.Close()
+
+	bitmaps, err := client.Bitmaps(context.Background(), "dev")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(173682688), bitmaps.Sizes.Used)
+	require.Len(t, bitmaps.Bitmaps, 3)
+	assert.Equal(t, 1920, bitmaps.Bitmaps[1].Width)
+
+	top := bitmaps.Top(2)
+	require.Len(t, top, 2)
+	assert.Equal(t, "pkg:/images/background.jpg", top[0].Name)
+	assert.Equal(t, "font cache", top[1].Name)
+	assert.Equal(t, "pkg:/images/icon.png", bitmaps.Bitmaps[0].Name, "Top should not reorder the original list")
+}
+
+func TestClient_FrameRate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, EndpointFrameRate, r.URL.Path)
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `<graphics-frame-rate><frame-rate>59.8</frame-rate><status>OK</status></graphics-frame-rate>`)
+		})
+		defer server.Close()
+
+		frameRate, err := client.FrameRate(context.Background())
+
+		require.NoError(t, err)
+		assert.InDelta(t, 59.8, frameRate.FPS, 0.001)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `<graphics-frame-rate><status>FAILED</status><error>No dev channel</error></graphics-frame-rate>`)
+		})
+		defer server.Close()
+
+		frameRate, err := client.FrameRate(context.Background())
+
+		assert.Error(t, err)
+		assert.Nil(t, frameRate)
+	})
+}
+
+func TestClient_SetLogger(t *testing.T) {
+	server, client := newMockServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, mockXMLResponse(EndpointActiveApp))
+	})
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	_, err := client.ActiveApp(context.Background())
+	require.NoError(t, err)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "ecp request", entry["msg"])
+	assert.Equal(t, "127.0.0.1", entry["device"])
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "http://127.0.0.1:8060"+EndpointActiveApp, entry["url"])
+	assert.Equal(t, float64(http.StatusOK), entry["status"])
+	assert.Equal(t, float64(len(mockXMLResponse(EndpointActiveApp))), entry["bytes"])
+	assert.Contains(t, entry, "latency")
+}
@@ -0,0 +1,247 @@
+package api
+
+/*
+Roku Docs
+Developer Application Installer
+https://developer.roku.com/docs/developer-program/getting-started/developer-setup.md
+*/
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const DevPort = 80
+const DevUsername = "rokudev"
+
+// DevTimeout is longer than DefaultTimeout since installs upload whole packages
+const DevTimeout = 60 * time.Second
+
+const (
+	EndpointPluginInstall = "/plugin_install"
+	EndpointPluginInspect = "/plugin_inspect"
+)
+
+// screenshotPattern finds the screenshot link in the installer's HTML response
+var screenshotPattern = regexp.MustCompile(`pkgs/dev\.(jpg|png)`)
+
+// installerMessagePatterns find status messages in the installer's HTML. Newer
+// firmware renders them with Roku.Message scripts, older firmware with font tags.
+var installerMessagePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`'Set message content', '([^']*)'`),
+	regexp.MustCompile(`<font color="red">([^<]*)</font>`),
+}
+
+// InstallerResult holds the status messages reported by the developer installer
+type InstallerResult struct {
+	Messages []string `json:"messages"`
+}
+
+// Failed reports whether any message describes a failure
+func (r *InstallerResult) Failed() bool {
+	for _, m := range r.Messages {
+		lower := strings.ToLower(m)
+		if strings.Contains(lower, "failure") || strings.Contains(lower, "failed") || strings.Contains(lower, "error") {
+			return true
+		}
+	}
+	return false
+}
+
+// String joins the messages into a single line
+func (r *InstallerResult) String() string {
+	return strings.Join(r.Messages, " ")
+}
+
+// parseInstallerResult extracts the status messages from an installer page
+func parseInstallerResult(page string) *InstallerResult {
+	result := &InstallerResult{}
+	for _, pattern := range installerMessagePatterns {
+		for _, match := range pattern.FindAllStringSubmatch(page, -1) {
+			if msg := strings.TrimSpace(match[1]); msg != "" {
+				result.Messages = append(result.Messages, msg)
+			}
+		}
+	}
+	return result
+}
+
+// Screenshot is an image captured from the running sideloaded channel
+type Screenshot struct {
+	Data        []byte
+	ContentType string
+	// Ext is the file extension reported by the device, "jpg" or "png"
+	Ext string
+}
+
+// DevClient talks to the developer application installer on port 80, which is
+// only available when developer mode is enabled on the device
+type DevClient struct {
+	// IP address of the Roku device
+	ip string
+	// client is an HTTP client that answers digest auth challenges
+	client *http.Client
+}
+
+// NewDevClient creates a developer installer client authenticating as rokudev
+// with the given password
+func NewDevClient(ip, password string, client *http.Client) *DevClient {
+	if client == nil {
+		client = &http.Client{Timeout: DevTimeout}
+	}
+	authed := *client
+	authed.Transport = newDigestTransport(DevUsername, password, client.Transport)
+	return &DevClient{
+		ip:     ip,
+		client: &authed,
+	}
+}
+
+// Screenshot captures the screen of the running sideloaded channel
+func (c *DevClient) Screenshot(ctx context.Context) (*Screenshot, error) {
+	page, err := c.postForm(ctx, EndpointPluginInspect, map[string]string{"mysubmit": "Screenshot"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to take screenshot: %w", err)
+	}
+	match := screenshotPattern.FindStringSubmatch(page)
+	if match == nil {
+		return nil, &DeviceError{Op: "screenshot", IP: c.ip, Message: "installer returned no image, is a sideloaded channel running?"}
+	}
+
+	data, contentType, err := c.get(ctx, "/"+match[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to download screenshot: %w", err)
+	}
+	return &Screenshot{Data: data, ContentType: contentType, Ext: match[1]}, nil
+}
+
+// Install uploads a zipped channel, replacing any sideloaded channel
+func (c *DevClient) Install(ctx context.Context, archive []byte) (*InstallerResult, error) {
+	return c.installer(ctx, "install", EndpointPluginInstall,
+		map[string]string{"mysubmit": "Replace"},
+		formFile{field: "archive", name: "channel.zip", data: archive})
+}
+
+// Delete removes the sideloaded channel
+func (c *DevClient) Delete(ctx context.Context) (*InstallerResult, error) {
+	return c.installer(ctx, "delete", EndpointPluginInstall, map[string]string{"mysubmit": "Delete"})
+}
+
+// Rekey sets the device's signing key from a previously signed package and
+// the password generated when that key was created
+func (c *DevClient) Rekey(ctx context.Context, signedPackage []byte, password string) (*InstallerResult, error) {
+	return c.installer(ctx, "rekey", EndpointPluginInspect,
+		map[string]string{"mysubmit": "Rekey", "passwd": password},
+		formFile{field: "archive", name: "signed.pkg", data: signedPackage})
+}
+
+// installer posts a form and turns failure messages in the page into an error
+func (c *DevClient) installer(ctx context.Context, op, endpoint string, fields map[string]string, files ...formFile) (*InstallerResult, error) {
+	page, err := c.postForm(ctx, endpoint, fields, files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s channel: %w", op, err)
+	}
+	result := parseInstallerResult(page)
+	if result.Failed() {
+		return result, &DeviceError{Op: op, IP: c.ip, Message: result.String()}
+	}
+	return result, nil
+}
+
+func (c *DevClient) url(endpoint string) string {
+	return fmt.Sprintf("http://%s:%d%s", c.ip, DevPort, endpoint)
+}
+
+// postForm submits a multipart form to the installer and returns the HTML
+// response. Files are sent as form file parts, fields as plain values.
+func (c *DevClient) postForm(ctx context.Context, endpoint string, fields map[string]string, files ...formFile) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			return "", err
+		}
+	}
+	// The installer expects an archive part even for requests that don't upload one
+	if len(files) == 0 {
+		files = append(files, formFile{field: "archive"})
+	}
+	for _, f := range files {
+		part, err := w.CreateFormFile(f.field, f.name)
+		if err != nil {
+			return "", err
+		}
+		if _, err := part.Write(f.data); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DevTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url(endpoint), bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for %s%s: %w", c.ip, endpoint, err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to perform request to %s%s: %w", c.ip, endpoint, err)
+	}
+	defer resp.Body.Close()
+	page, _ := io.ReadAll(resp.Body)
+	if err := devStatusError(c.ip, endpoint, resp.StatusCode, string(page)); err != nil {
+		return "", err
+	}
+	return string(page), nil
+}
+
+func (c *DevClient) get(ctx context.Context, endpoint string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, DevTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url(endpoint), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request for %s%s: %w", c.ip, endpoint, err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to perform request to %s%s: %w", c.ip, endpoint, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response from %s%s: %w", c.ip, endpoint, err)
+	}
+	if err := devStatusError(c.ip, endpoint, resp.StatusCode, string(data)); err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// devStatusError converts a non-2xx installer response into an error
+func devStatusError(ip, endpoint string, status int, body string) error {
+	if status >= 200 && status < 300 {
+		return nil
+	}
+	if status == http.StatusUnauthorized {
+		return &DeviceError{Op: "developer installer", IP: ip, Message: "authentication failed, check the developer password"}
+	}
+	return fmt.Errorf("unexpected status %d from %s%s: %s", status, ip, endpoint, strings.TrimSpace(body))
+}
+
+// formFile is a file part of a multipart installer request
+type formFile struct {
+	field string
+	name  string
+	data  []byte
+}
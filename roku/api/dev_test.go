@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDevPassword = "hunter2"
+
+// digestHandler wraps next with a digest auth check like the dev installer's
+func digestHandler(t *testing.T, next http.HandlerFunc) http.HandlerFunc {
+	t.Helper()
+	const realm, nonce = "rokudev", "n0nce"
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Digest ") {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", nonce="%s", qop="auth"`, realm, nonce))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		p := parseDigestChallenge(header)
+		ha1 := md5Hex(DevUsername + ":" + realm + ":" + testDevPassword)
+		ha2 := md5Hex(r.Method + ":" + p["uri"])
+		expected := md5Hex(strings.Join([]string{ha1, nonce, p["nc"], p["cnonce"], p["qop"], ha2}, ":"))
+		if p["username"] != DevUsername || p["response"] != expected {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func newMockDevServer(t *testing.T, password string, handler http.HandlerFunc) (*httptest.Server, *DevClient) {
+	t.Helper()
+	server := httptest.NewServer(digestHandler(t, handler))
+	httpClient := &http.Client{
+		Timeout: DefaultTimeout,
+		Transport: &customTransport{
+			testServerURL: server.URL,
+			base:          http.DefaultTransport,
+		},
+	}
+	return server, NewDevClient("127.0.0.1", password, httpClient)
+}
+
+func TestDevClient_Screenshot(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		server, client := newMockDevServer(t, testDevPassword, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case EndpointPluginInspect:
+				assert.Equal(t, "POST", r.Method)
+				require.NoError(t, r.ParseMultipartForm(1<<20))
+				assert.Equal(t, "Screenshot", r.FormValue("mysubmit"))
+				fmt.Fprint(w, `<html><img src="pkgs/dev.jpg?time=1700000000"></html>`)
+			case "/pkgs/dev.jpg":
+				w.Header().Set("Content-Type", "image/jpeg")
+				fmt.Fprint(w, "JPEGDATA")
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+		defer server.Close()
+
+		shot, err := client.Screenshot(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, "jpg", shot.Ext)
+		assert.Equal(t, "image/jpeg", shot.ContentType)
+		assert.Equal(t, []byte("JPEGDATA"), shot.Data)
+	})
+
+	t.Run("NoChannelRunning", func(t *testing.T) {
+		server, client := newMockDevServer(t, testDevPassword, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<html>No dev channel installed</html>`)
+		})
+		defer server.Close()
+
+		shot, err := client.Screenshot(context.Background())
+
+		assert.Error(t, err)
+		assert.Nil(t, shot)
+		assert.Contains(t, err.Error(), "sideloaded channel")
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		server, client := newMockDevServer(t, "wrong", func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("handler should not be reached")
+		})
+		defer server.Close()
+
+		shot, err := client.Screenshot(context.Background())
+
+		assert.Error(t, err)
+		assert.Nil(t, shot)
+		assert.Contains(t, err.Error(), "authentication failed")
+	})
+}
+
+func TestParseDigestChallenge(t *testing.T) {
+	p := parseDigestChallenge(`Digest realm="rokudev", nonce="a,b", qop="auth"`)
+
+	assert.Equal(t, "rokudev", p["realm"])
+	assert.Equal(t, "a,b", p["nonce"])
+	assert.Equal(t, "auth", p["qop"])
+}
+
+func TestDevClient_Install(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		server, client := newMockDevServer(t, testDevPassword, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, EndpointPluginInstall, r.URL.Path)
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			assert.Equal(t, "Replace", r.FormValue("mysubmit"))
+			file, _, err := r.FormFile("archive")
+			require.NoError(t, err)
+			defer file.Close()
+			fmt.Fprint(w, `<script>node.appendChild(Shell.create('Roku.Message').trigger('Set message type', 'success').trigger('Set message content', 'Received 42 bytes.').trigger('Render', node));</script>
+<script>node.appendChild(Shell.create('Roku.Message').trigger('Set message type', 'success').trigger('Set message content', 'Install Success.').trigger('Render', node));</script>`)
+		})
+		defer server.Close()
+
+		result, err := client.Install(context.Background(), []byte("PK"))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Received 42 bytes.", "Install Success."}, result.Messages)
+	})
+
+	t.Run("CompileFailure", func(t *testing.T) {
+		server, client := newMockDevServer(t, testDevPassword, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<font color="red">Install Failure: Compilation Failed.</font>`)
+		})
+		defer server.Close()
+
+		result, err := client.Install(context.Background(), []byte("PK"))
+
+		assert.Error(t, err)
+		require.NotNil(t, result)
+		assert.True(t, result.Failed())
+		assert.Contains(t, err.Error(), "Compilation Failed")
+	})
+}
+
+func TestDevClient_Delete(t *testing.T) {
+	server, client := newMockDevServer(t, testDevPassword, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, EndpointPluginInstall, r.URL.Path)
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "Delete", r.FormValue("mysubmit"))
+		fmt.Fprint(w, `<font color="red">Delete Succeeded.</font>`)
+	})
+	defer server.Close()
+
+	result, err := client.Delete(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "Delete Succeeded.", result.String())
+}
+
+func TestDevClient_Rekey(t *testing.T) {
+	server, client := newMockDevServer(t, testDevPassword, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, EndpointPluginInspect, r.URL.Path)
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "Rekey", r.FormValue("mysubmit"))
+		assert.Equal(t, "signing-pass", r.FormValue("passwd"))
+		fmt.Fprint(w, `<font color="red">Success.</font>`)
+	})
+	defer server.Close()
+
+	result, err := client.Rekey(context.Background(), []byte("PKG"), "signing-pass")
+
+	require.NoError(t, err)
+	assert.False(t, result.Failed())
+}
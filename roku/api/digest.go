@@ -0,0 +1,159 @@
+package api
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// digestTransport adds HTTP digest authentication (RFC 2617, MD5, qop=auth)
+// as used by the Roku developer installer
+type digestTransport struct {
+	username string
+	password string
+	base     http.RoundTripper
+
+	mu        sync.Mutex
+	challenge map[string]string
+	nc        int
+}
+
+func newDigestTransport(username, password string, base http.RoundTripper) *digestTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &digestTransport{username: username, password: password, base: base}
+}
+
+// RoundTrip sends the request, answering a digest challenge if one is returned.
+// Once a challenge has been seen it is reused to authenticate up front.
+func (t *digestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if auth := t.authorization(req); auth != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", auth)
+		if err := rewindBody(req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	header := resp.Header.Get("WWW-Authenticate")
+	if !strings.HasPrefix(strings.ToLower(header), "digest ") {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	t.mu.Lock()
+	t.challenge = parseDigestChallenge(header)
+	t.nc = 0
+	t.mu.Unlock()
+
+	retry := req.Clone(req.Context())
+	if err := rewindBody(retry); err != nil {
+		return nil, err
+	}
+	retry.Header.Set("Authorization", t.authorization(retry))
+	return t.base.RoundTrip(retry)
+}
+
+// authorization builds the Authorization header for req from the cached
+// challenge, or returns "" if no challenge has been received yet
+func (t *digestTransport) authorization(req *http.Request) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.challenge == nil {
+		return ""
+	}
+	t.nc++
+
+	realm := t.challenge["realm"]
+	nonce := t.challenge["nonce"]
+	uri := req.URL.RequestURI()
+	ha1 := md5Hex(t.username + ":" + realm + ":" + t.password)
+	ha2 := md5Hex(req.Method + ":" + uri)
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, t.username),
+		fmt.Sprintf(`realm="%s"`, realm),
+		fmt.Sprintf(`nonce="%s"`, nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+	}
+	if qop := t.challenge["qop"]; qop != "" {
+		nc := fmt.Sprintf("%08x", t.nc)
+		cnonce := randomHex(8)
+		response := md5Hex(strings.Join([]string{ha1, nonce, nc, cnonce, "auth", ha2}, ":"))
+		parts = append(parts, "qop=auth", "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce), fmt.Sprintf(`response="%s"`, response))
+	} else {
+		parts = append(parts, fmt.Sprintf(`response="%s"`, md5Hex(ha1+":"+nonce+":"+ha2)))
+	}
+	if opaque := t.challenge["opaque"]; opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, opaque))
+	}
+	return "Digest " + strings.Join(parts, ", ")
+}
+
+// parseDigestChallenge parses the key/value pairs of a WWW-Authenticate header
+func parseDigestChallenge(header string) map[string]string {
+	params := make(map[string]string)
+	header = strings.TrimSpace(header[len("Digest "):])
+	for _, part := range splitDigestParams(header) {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		params[strings.ToLower(key)] = strings.Trim(value, `"`)
+	}
+	return params
+}
+
+// splitDigestParams splits on commas that are not inside quoted values
+func splitDigestParams(s string) []string {
+	var parts []string
+	var quoted bool
+	start := 0
+	for i, r := range s {
+		switch r {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// rewindBody resets the request body so it can be sent again
+func rewindBody(req *http.Request) error {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return fmt.Errorf("failed to rewind request body: %w", err)
+	}
+	req.Body = body
+	return nil
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,379 @@
+package api
+
+/*
+ECP-2 is the WebSocket transport used by the official mobile apps on newer
+firmware. Messages are flat JSON objects; requests carry a "request" name and a
+"request-id", responses echo it back as "response-id", and unsolicited
+"notify" messages are pushed after subscribing with "request-events".
+*/
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	EndpointECP2Session = "/ecp-session"
+	ECP2Subprotocol     = "ecp-2"
+)
+
+// ecp2AuthKey is the shared secret mixed into the authentication challenge
+const ecp2AuthKey = "95E610D0-7C29-44EF-FB0F-97F1FCE4C297"
+
+// Notification names that can be subscribed to over ECP-2
+const (
+	NotifyMediaPlayerStateChanged = "media-player-state-changed"
+	NotifyPowerModeChanged        = "power-mode-changed"
+	NotifyVolumeChanged           = "volume-changed"
+)
+
+// ErrNotSent is wrapped by request errors when the request never reached the
+// device, so it is safe to retry over another transport
+var ErrNotSent = errors.New("request was not sent")
+
+// ECP2Message is a single ECP-2 frame. All values are carried as strings.
+type ECP2Message map[string]string
+
+// UnmarshalJSON accepts non-string values by keeping their raw JSON text
+func (m *ECP2Message) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	msg := make(ECP2Message, len(raw))
+	for k, v := range raw {
+		var s string
+		if err := json.Unmarshal(v, &s); err != nil {
+			s = string(v)
+		}
+		msg[k] = s
+	}
+	*m = msg
+	return nil
+}
+
+// Notification is a pushed ECP-2 event such as a media player state change
+type Notification struct {
+	Name   string            `json:"name"`
+	Params map[string]string `json:"params"`
+}
+
+// ECP2Error is returned when the device answers a request with a non-200 status
+type ECP2Error struct {
+	Request string
+	Status  string
+	Message string
+}
+
+func (e *ECP2Error) Error() string {
+	return fmt.Sprintf("ecp-2 %s failed with status %s: %s", e.Request, e.Status, e.Message)
+}
+
+// ECP2Session is an authenticated ECP-2 WebSocket connection to a Roku device
+type ECP2Session struct {
+	ip   string
+	conn *websocket.Conn
+
+	// writeMu serialises writes, which gorilla/websocket requires
+	writeMu sync.Mutex
+	nextID  atomic.Uint64
+
+	mu      sync.Mutex
+	pending map[string]chan ECP2Message
+	err     error
+
+	notifications chan Notification
+	// lost is signalled when a notification is dropped because nobody was
+	// reading, after which the pushed state can no longer be trusted
+	lost chan struct{}
+	done chan struct{}
+}
+
+// DialECP2 opens an ECP-2 session to the device and completes the
+// challenge/response handshake. A nil dialer uses websocket.DefaultDialer.
+func DialECP2(ctx context.Context, ip string, dialer *websocket.Dialer) (*ECP2Session, error) {
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	d := *dialer
+	d.Subprotocols = []string{ECP2Subprotocol}
+	if d.HandshakeTimeout == 0 {
+		d.HandshakeTimeout = DefaultTimeout
+	}
+
+	url := fmt.Sprintf("ws://%s:%d%s", ip, RokuPort, EndpointECP2Session)
+	conn, resp, err := d.DialContext(ctx, url, http.Header{})
+	if err != nil {
+		if resp != nil {
+			return nil, &DeviceError{Op: "ecp-2 dial", IP: ip, Message: fmt.Sprintf("unexpected status %d", resp.StatusCode), Err: err}
+		}
+		return nil, &DeviceError{Op: "ecp-2 dial", IP: ip, Message: "unable to open session", Err: err}
+	}
+
+	s := &ECP2Session{
+		ip:            ip,
+		conn:          conn,
+		pending:       make(map[string]chan ECP2Message),
+		notifications: make(chan Notification, 16),
+		lost:          make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+	if err := s.authenticate(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go s.readLoop()
+	return s, nil
+}
+
+// authenticate answers the challenge the device sends on connect
+func (s *ECP2Session) authenticate(ctx context.Context) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.conn.SetReadDeadline(deadline)
+		defer func() { _ = s.conn.SetReadDeadline(time.Time{}) }()
+	}
+
+	var challenge ECP2Message
+	if err := s.conn.ReadJSON(&challenge); err != nil {
+		return &DeviceError{Op: "ecp-2 authenticate", IP: s.ip, Message: "no challenge received", Err: err}
+	}
+	if challenge["notify"] != "authenticate" {
+		return &DeviceError{Op: "ecp-2 authenticate", IP: s.ip, Message: fmt.Sprintf("unexpected first message %q", challenge["notify"])}
+	}
+
+	id := s.requestID()
+	err := s.write(ECP2Message{
+		"request":        "authenticate",
+		"request-id":     id,
+		"param-response": ECP2AuthResponse(challenge["param-challenge"]),
+	})
+	if err != nil {
+		return &DeviceError{Op: "ecp-2 authenticate", IP: s.ip, Message: "unable to send response", Err: err}
+	}
+
+	for {
+		var msg ECP2Message
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			return &DeviceError{Op: "ecp-2 authenticate", IP: s.ip, Message: "no response received", Err: err}
+		}
+		if msg["response-id"] != id {
+			continue
+		}
+		if msg["status"] != "200" {
+			return &DeviceError{Op: "ecp-2 authenticate", IP: s.ip, Message: "rejected", Err: &ECP2Error{Request: "authenticate", Status: msg["status"], Message: msg["status-msg"]}}
+		}
+		return nil
+	}
+}
+
+// ECP2AuthResponse computes the answer to an ECP-2 authentication challenge
+func ECP2AuthResponse(challenge string) string {
+	sum := sha1.Sum([]byte(challenge + ecp2AuthKey))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// readLoop dispatches responses to waiting requests and forwards notifications
+func (s *ECP2Session) readLoop() {
+	defer close(s.notifications)
+	for {
+		var msg ECP2Message
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			s.fail(err)
+			return
+		}
+
+		if id, ok := msg["response-id"]; ok {
+			s.mu.Lock()
+			ch := s.pending[id]
+			delete(s.pending, id)
+			s.mu.Unlock()
+			if ch != nil {
+				ch <- msg
+			}
+			continue
+		}
+
+		if name, ok := msg["notify"]; ok {
+			n := Notification{Name: name, Params: make(map[string]string)}
+			for k, v := range msg {
+				if k != "notify" {
+					n.Params[k] = v
+				}
+			}
+			select {
+			case s.notifications <- n:
+			default:
+				// Drop notifications nobody is reading rather than stall
+				// responses, and tell the reader its state is now stale
+				select {
+				case s.lost <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// fail records the terminal error and releases every waiting request
+func (s *ECP2Session) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.err = err
+	close(s.done)
+	for id, ch := range s.pending {
+		close(ch)
+		delete(s.pending, id)
+	}
+}
+
+func (s *ECP2Session) requestID() string {
+	return strconv.FormatUint(s.nextID.Add(1), 10)
+}
+
+func (s *ECP2Session) write(msg ECP2Message) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteJSON(msg)
+}
+
+// Request sends a named request with optional parameters and waits for its
+// response. Parameter names are sent with the "param-" prefix added.
+func (s *ECP2Session) Request(ctx context.Context, request string, params map[string]string) (ECP2Message, error) {
+	id := s.requestID()
+	msg := ECP2Message{"request": request, "request-id": id}
+	for k, v := range params {
+		msg["param-"+k] = v
+	}
+
+	ch := make(chan ECP2Message, 1)
+	s.mu.Lock()
+	if s.err != nil {
+		err := s.err
+		s.mu.Unlock()
+		return nil, &DeviceError{Op: "ecp-2 " + request, IP: s.ip, Message: "session closed", Err: fmt.Errorf("%w: %w", ErrNotSent, err)}
+	}
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	if err := s.write(msg); err != nil {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+		return nil, &DeviceError{Op: "ecp-2 " + request, IP: s.ip, Message: "unable to send request", Err: fmt.Errorf("%w: %w", ErrNotSent, err)}
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, &DeviceError{Op: "ecp-2 " + request, IP: s.ip, Message: "session closed", Err: s.Err()}
+		}
+		if resp["status"] != "200" {
+			return resp, &ECP2Error{Request: request, Status: resp["status"], Message: resp["status-msg"]}
+		}
+		return resp, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// Query issues a query-* request and decodes the base64 XML content into target
+func (s *ECP2Session) Query(ctx context.Context, request string, target interface{}) error {
+	resp, err := s.Request(ctx, request, nil)
+	if err != nil {
+		return err
+	}
+	data, err := base64.StdEncoding.DecodeString(resp["content-data"])
+	if err != nil {
+		return fmt.Errorf("invalid content-data in ecp-2 %s response: %w", request, err)
+	}
+	return xml.Unmarshal(data, target)
+}
+
+// DeviceInfo retrieves detailed device information over the session
+func (s *ECP2Session) DeviceInfo(ctx context.Context) (*DeviceInfo, error) {
+	var deviceInfo DeviceInfo
+	if err := s.Query(ctx, "query-device-info", &deviceInfo); err != nil {
+		return nil, fmt.Errorf("failed to get device info: %w", err)
+	}
+	return &deviceInfo, nil
+}
+
+// MediaPlayer retrieves the current media player state over the session
+func (s *ECP2Session) MediaPlayer(ctx context.Context) (*Player, error) {
+	var player Player
+	if err := s.Query(ctx, "query-media-player", &player); err != nil {
+		return nil, fmt.Errorf("failed to get media player: %w", err)
+	}
+	return &player, nil
+}
+
+// Keypress sends a keypress over the session
+func (s *ECP2Session) Keypress(ctx context.Context, key Key) error {
+	if !key.Valid() {
+		return fmt.Errorf("invalid action '%s' for device %s", key, s.ip)
+	}
+	_, err := s.Request(ctx, "key-press", map[string]string{"key": string(key)})
+	return err
+}
+
+// Subscribe asks the device to push the named notifications
+func (s *ECP2Session) Subscribe(ctx context.Context, names ...string) error {
+	events := make([]string, len(names))
+	for i, name := range names {
+		events[i] = "+" + name
+	}
+	_, err := s.Request(ctx, "request-events", map[string]string{"events": strings.Join(events, ",")})
+	return err
+}
+
+// Notifications returns the channel of pushed notifications. It is closed
+// when the session ends.
+func (s *ECP2Session) Notifications() <-chan Notification {
+	return s.notifications
+}
+
+// Lost receives a value after notifications were dropped because the
+// channel was full. The reader has missed changes and should re-query the
+// state it tracks.
+func (s *ECP2Session) Lost() <-chan struct{} {
+	return s.lost
+}
+
+// Done is closed when the session ends
+func (s *ECP2Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that ended the session, if any
+func (s *ECP2Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close ends the session
+func (s *ECP2Session) Close() error {
+	s.writeMu.Lock()
+	_ = s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	s.writeMu.Unlock()
+	return s.conn.Close()
+}
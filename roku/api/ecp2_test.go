@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newECP2Server starts a WebSocket stand-in for a Roku's ECP-2 endpoint.
+// handle is called for every request after a successful handshake.
+func newECP2Server(t *testing.T, handle func(conn *websocket.Conn, msg ECP2Message)) (*httptest.Server, *websocket.Dialer) {
+	t.Helper()
+	upgrader := websocket.Upgrader{Subprotocols: []string{ECP2Subprotocol}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, EndpointECP2Session, r.URL.Path)
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		challenge := "abc123"
+		_ = conn.WriteJSON(ECP2Message{"notify": "authenticate", "param-challenge": challenge})
+		var auth ECP2Message
+		if err := conn.ReadJSON(&auth); err != nil {
+			return
+		}
+		status := "200"
+		if auth["param-response"] != ECP2AuthResponse(challenge) {
+			status = "401"
+		}
+		_ = conn.WriteJSON(ECP2Message{"response": "authenticate", "response-id": auth["request-id"], "status": status})
+
+		for {
+			var msg ECP2Message
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			handle(conn, msg)
+		}
+	}))
+
+	// Redirect the fixed Roku port to the test server
+	dialer := &websocket.Dialer{
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, strings.TrimPrefix(server.URL, "http://"))
+		},
+	}
+	return server, dialer
+}
+
+func TestDialECP2(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		server, dialer := newECP2Server(t, func(conn *websocket.Conn, msg ECP2Message) {})
+		defer server.Close()
+
+		session, err := DialECP2(context.Background(), "127.0.0.1", dialer)
+
+		require.NoError(t, err)
+		assert.NoError(t, session.Err())
+		assert.NoError(t, session.Close())
+	})
+
+	t.Run("NotSupported", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+		dialer := &websocket.Dialer{
+			NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, strings.TrimPrefix(server.URL, "http://"))
+			},
+		}
+
+		session, err := DialECP2(context.Background(), "127.0.0.1", dialer)
+
+		assert.Error(t, err)
+		assert.Nil(t, session)
+		assert.Contains(t, err.Error(), "unexpected status 404")
+	})
+}
+
+func TestECP2Session_Requests(t *testing.T) {
+	server, dialer := newECP2Server(t, func(conn *websocket.Conn, msg ECP2Message) {
+		resp := ECP2Message{"response": msg["request"], "response-id": msg["request-id"], "status": "200"}
+		switch msg["request"] {
+		case "key-press":
+			if msg["param-key"] != "Home" {
+				resp["status"] = "400"
+				resp["status-msg"] = "bad key"
+			}
+		case "query-device-info":
+			resp["content-type"] = "text/xml; charset=\"utf-8\""
+			resp["content-data"] = base64.StdEncoding.EncodeToString([]byte(`<device-info><serial-number>X1</serial-number><power-mode>PowerOn</power-mode></device-info>`))
+		case "request-events":
+			_ = conn.WriteJSON(resp)
+			_ = conn.WriteJSON(ECP2Message{"notify": NotifyPowerModeChanged, "param-power-mode": "DisplayOff"})
+			return
+		}
+		_ = conn.WriteJSON(resp)
+	})
+	defer server.Close()
+
+	session, err := DialECP2(context.Background(), "127.0.0.1", dialer)
+	require.NoError(t, err)
+	defer session.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Keypress", func(t *testing.T) {
+		assert.NoError(t, session.Keypress(ctx, KeyHome))
+	})
+
+	t.Run("KeypressRejected", func(t *testing.T) {
+		err := session.Keypress(ctx, KeySelect)
+
+		var ecpErr *ECP2Error
+		require.ErrorAs(t, err, &ecpErr)
+		assert.Equal(t, "400", ecpErr.Status)
+	})
+
+	t.Run("InvalidAction", func(t *testing.T) {
+		err := session.Keypress(ctx, "bogus")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid action")
+	})
+
+	t.Run("DeviceInfo", func(t *testing.T) {
+		info, err := session.DeviceInfo(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, "X1", info.SerialNumber)
+		assert.Equal(t, "PowerOn", info.PowerMode)
+	})
+
+	t.Run("Subscribe", func(t *testing.T) {
+		require.NoError(t, session.Subscribe(ctx, NotifyPowerModeChanged))
+
+		select {
+		case n := <-session.Notifications():
+			assert.Equal(t, NotifyPowerModeChanged, n.Name)
+			assert.Equal(t, "DisplayOff", n.Params["param-power-mode"])
+		case <-ctx.Done():
+			t.Fatal("no notification received")
+		}
+	})
+}
+
+func TestECP2Session_Closed(t *testing.T) {
+	server, dialer := newECP2Server(t, func(conn *websocket.Conn, msg ECP2Message) {
+		conn.Close()
+	})
+	defer server.Close()
+
+	session, err := DialECP2(context.Background(), "127.0.0.1", dialer)
+	require.NoError(t, err)
+
+	err = session.Keypress(context.Background(), KeyHome)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotSent)
+
+	<-session.Done()
+	assert.Error(t, session.Err())
+
+	err = session.Keypress(context.Background(), KeyHome)
+	assert.ErrorIs(t, err, ErrNotSent)
+}
+
+func TestECP2Session_Lost(t *testing.T) {
+	server, dialer := newECP2Server(t, func(conn *websocket.Conn, msg ECP2Message) {
+		_ = conn.WriteJSON(ECP2Message{"response": msg["request"], "response-id": msg["request-id"], "status": "200"})
+		for i := 0; i < 20; i++ {
+			_ = conn.WriteJSON(ECP2Message{"notify": NotifyMediaPlayerStateChanged, "param-media-player-state": "play"})
+		}
+	})
+	defer server.Close()
+
+	session, err := DialECP2(context.Background(), "127.0.0.1", dialer)
+	require.NoError(t, err)
+	defer session.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, session.Subscribe(ctx, NotifyMediaPlayerStateChanged))
+
+	select {
+	case <-session.Lost():
+	case <-ctx.Done():
+		t.Fatal("dropped notifications were not reported")
+	}
+	assert.Len(t, session.Notifications(), 16)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Key is an ECP key value as sent to /keypress, such as Home or VolumeUp
+// - Docs: https://developer.roku.com/docs/developer-program/debugging/external-control-api.md#keypress-key-values
+type Key string
+
+// Navigation keys
+const (
+	KeyHome      Key = "Home"
+	KeyBack      Key = "Back"
+	KeyUp        Key = "Up"
+	KeyDown      Key = "Down"
+	KeyLeft      Key = "Left"
+	KeyRight     Key = "Right"
+	KeySelect    Key = "Select"
+	KeyInfo      Key = "Info"
+	KeyBackspace Key = "Backspace"
+	KeySearch    Key = "Search"
+	KeyEnter     Key = "Enter"
+)
+
+// Playback keys
+const (
+	KeyPlay          Key = "Play"
+	KeyRev           Key = "Rev"
+	KeyFwd           Key = "Fwd"
+	KeyInstantReplay Key = "InstantReplay"
+)
+
+// Device keys
+const (
+	KeyFindRemote  Key = "FindRemote"
+	KeyVolumeUp    Key = "VolumeUp"
+	KeyVolumeDown  Key = "VolumeDown"
+	KeyVolumeMute  Key = "VolumeMute"
+	KeyPower       Key = "Power"
+	KeyPowerOn     Key = "PowerOn"
+	KeyPowerOff    Key = "PowerOff"
+	KeySleep       Key = "Sleep"
+	KeyChannelUp   Key = "ChannelUp"
+	KeyChannelDown Key = "ChannelDown"
+	KeyInputTuner  Key = "InputTuner"
+	KeyInputHDMI1  Key = "InputHDMI1"
+	KeyInputHDMI2  Key = "InputHDMI2"
+	KeyInputHDMI3  Key = "InputHDMI3"
+	KeyInputHDMI4  Key = "InputHDMI4"
+	KeyInputAV1    Key = "InputAV1"
+)
+
+// KeySpec describes a key
+type KeySpec struct {
+	Key Key
+	// Name is the short lowercase name used on the command line
+	Name string
+	// Aliases are other names ParseKey accepts
+	Aliases     []string
+	Description string
+	// NeedsTV is set for keys that only work on Roku TVs and audio devices
+	NeedsTV bool
+}
+
+// Keys lists every supported key in a stable order, grouped by purpose
+var Keys = []KeySpec{
+	{Key: KeyHome, Name: "home", Description: "Go to the home screen"},
+	{Key: KeyBack, Name: "back", Description: "Go back"},
+	{Key: KeyUp, Name: "up", Description: "Move up"},
+	{Key: KeyDown, Name: "down", Description: "Move down"},
+	{Key: KeyLeft, Name: "left", Description: "Move left"},
+	{Key: KeyRight, Name: "right", Description: "Move right"},
+	{Key: KeySelect, Name: "select", Aliases: []string{"ok"}, Description: "Select the highlighted item"},
+	{Key: KeyInfo, Name: "info", Aliases: []string{"options", "star"}, Description: "Show options"},
+	{Key: KeyBackspace, Name: "backspace", Description: "Delete a character"},
+	{Key: KeySearch, Name: "search", Description: "Open search"},
+	{Key: KeyEnter, Name: "enter", Description: "Submit text entry"},
+	{Key: KeyPlay, Name: "play", Aliases: []string{"pause", "playpause"}, Description: "Play or pause"},
+	{Key: KeyRev, Name: "rev", Aliases: []string{"rewind"}, Description: "Rewind"},
+	{Key: KeyFwd, Name: "fwd", Aliases: []string{"forward", "fastforward", "ff"}, Description: "Fast forward"},
+	{Key: KeyInstantReplay, Name: "replay", Description: "Replay the last few seconds"},
+	{Key: KeyFindRemote, Name: "find", Aliases: []string{"findremote"}, Description: "Make the remote play a sound"},
+	{Key: KeyVolumeUp, Name: "volumeup", Aliases: []string{"volup", "vol+"}, Description: "Turn the volume up", NeedsTV: true},
+	{Key: KeyVolumeDown, Name: "volumedown", Aliases: []string{"voldown", "vol-"}, Description: "Turn the volume down", NeedsTV: true},
+	{Key: KeyVolumeMute, Name: "mute", Description: "Toggle mute", NeedsTV: true},
+	{Key: KeyPower, Name: "power", Description: "Toggle power", NeedsTV: true},
+	{Key: KeyPowerOn, Name: "poweron", Aliases: []string{"on"}, Description: "Turn on", NeedsTV: true},
+	{Key: KeyPowerOff, Name: "poweroff", Aliases: []string{"off"}, Description: "Turn off", NeedsTV: true},
+	{Key: KeySleep, Name: "sleep", Description: "Start the TV sleep timer", NeedsTV: true},
+	{Key: KeyChannelUp, Name: "channelup", Aliases: []string{"chup"}, Description: "Next live TV channel", NeedsTV: true},
+	{Key: KeyChannelDown, Name: "channeldown", Aliases: []string{"chdown"}, Description: "Previous live TV channel", NeedsTV: true},
+	{Key: KeyInputTuner, Name: "tuner", Aliases: []string{"livetv", "antenna"}, Description: "Switch to live TV", NeedsTV: true},
+	{Key: KeyInputHDMI1, Name: "hdmi1", Description: "Switch to HDMI 1", NeedsTV: true},
+	{Key: KeyInputHDMI2, Name: "hdmi2", Description: "Switch to HDMI 2", NeedsTV: true},
+	{Key: KeyInputHDMI3, Name: "hdmi3", Description: "Switch to HDMI 3", NeedsTV: true},
+	{Key: KeyInputHDMI4, Name: "hdmi4", Description: "Switch to HDMI 4", NeedsTV: true},
+	{Key: KeyInputAV1, Name: "av1", Aliases: []string{"av"}, Description: "Switch to AV", NeedsTV: true},
+}
+
+// keyLookup maps every lowercase name, alias and key value to its key
+var keyLookup = func() map[string]int {
+	lookup := make(map[string]int)
+	for i, info := range Keys {
+		lookup[strings.ToLower(string(info.Key))] = i
+		lookup[info.Name] = i
+		for _, alias := range info.Aliases {
+			lookup[alias] = i
+		}
+	}
+	return lookup
+}()
+
+// ParseKey returns the key for a name such as home, an alias such as
+// rewind or a key value such as InputHDMI1, ignoring case
+func ParseKey(name string) (Key, error) {
+	i, ok := keyLookup[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", fmt.Errorf("invalid action '%s'", name)
+	}
+	return Keys[i].Key, nil
+}
+
+// Spec returns the description of the key
+func (k Key) Spec() (KeySpec, bool) {
+	i, ok := keyLookup[strings.ToLower(string(k))]
+	if !ok || Keys[i].Key != k {
+		return KeySpec{}, false
+	}
+	return Keys[i], true
+}
+
+// Valid reports whether k is a supported key value
+func (k Key) Valid() bool {
+	_, ok := k.Spec()
+	return ok
+}
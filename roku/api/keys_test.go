@@ -0,0 +1,61 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want Key
+	}{
+		{"home", KeyHome},
+		{"HOME", KeyHome},
+		{"HDMI1", KeyInputHDMI1},
+		{"hdmi1", KeyInputHDMI1},
+		{"InputHDMI1", KeyInputHDMI1},
+		{"rewind", KeyRev},
+		{"vol+", KeyVolumeUp},
+		{"mute", KeyVolumeMute},
+		{" poweron ", KeyPowerOn},
+		{"av", KeyInputAV1},
+		{"Sleep", KeySleep},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKey(tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, key)
+		})
+	}
+
+	_, err := ParseKey("dance")
+	assert.ErrorContains(t, err, "invalid action 'dance'")
+}
+
+func TestKeys(t *testing.T) {
+	names := make(map[string]bool)
+	for _, spec := range Keys {
+		assert.False(t, names[spec.Name], "duplicate name %s", spec.Name)
+		names[spec.Name] = true
+		assert.NotEmpty(t, spec.Description, spec.Name)
+
+		// Every name resolves back to its own key
+		key, err := ParseKey(spec.Name)
+		require.NoError(t, err)
+		assert.Equal(t, spec.Key, key)
+		got, ok := key.Spec()
+		require.True(t, ok)
+		assert.Equal(t, spec.Name, got.Name)
+	}
+
+	assert.True(t, KeyInputHDMI2.Valid())
+	assert.False(t, Key("hdmi2").Valid(), "names are not key values")
+	spec, _ := KeyInputHDMI2.Spec()
+	assert.True(t, spec.NeedsTV)
+	spec, _ = KeyHome.Spec()
+	assert.False(t, spec.NeedsTV)
+}
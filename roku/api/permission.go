@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PermissionMode is the device's "Control by mobile apps" setting, which
+// decides which ECP commands it accepts
+type PermissionMode string
+
+const (
+	PermissionUnknown PermissionMode = ""
+	// PermissionDisabled rejects every ECP command
+	PermissionDisabled PermissionMode = "disabled"
+	// PermissionLimited allows app launches, text entry and app queries
+	PermissionLimited PermissionMode = "limited"
+	// PermissionEnabled allows every command from the local network
+	PermissionEnabled PermissionMode = "enabled"
+	// PermissionPermissive allows every command from any network
+	PermissionPermissive PermissionMode = "permissive"
+)
+
+// Title is the name of the mode in the Roku settings menu, such as Limited
+func (m PermissionMode) Title() string {
+	if m == PermissionUnknown {
+		return "Unknown"
+	}
+	return strings.ToUpper(string(m[:1])) + string(m[1:])
+}
+
+// LimitedModeError is returned for commands refused because the device's
+// "Control by mobile apps" setting is Limited
+type LimitedModeError struct {
+	IP       string
+	Method   string
+	Endpoint string
+}
+
+func (e *LimitedModeError) Error() string {
+	return fmt.Sprintf("roku device %s is in Limited mode and refused %s %s. Set Settings > System > Advanced system settings > Control by mobile apps to Enabled, or run 'roku doctor' to see what works in Limited mode",
+		e.IP, e.Method, e.Endpoint)
+}
+
+// limitedModeError returns a LimitedModeError when the response shows the
+// device refused the request because of Limited mode
+func (c *Client) limitedModeError(req *http.Request, status int, body []byte) error {
+	if status != http.StatusForbidden || !strings.Contains(string(body), "Limited mode") {
+		return nil
+	}
+	return &LimitedModeError{IP: c.ip, Method: req.Method, Endpoint: req.URL.Path}
+}
+
+// reportedPermission maps the ecp-setting-mode reported in device-info to a
+// mode. Firmware reports "default" for Enabled, and unrecognised values give
+// PermissionUnknown.
+func reportedPermission(setting string) PermissionMode {
+	switch mode := PermissionMode(strings.ToLower(setting)); mode {
+	case PermissionDisabled, PermissionLimited, PermissionEnabled, PermissionPermissive:
+		return mode
+	case "default":
+		return PermissionEnabled
+	}
+	return PermissionUnknown
+}
+
+// ProbePermission works out the device's permission mode without changing
+// its state. Firmware that reports a known ecp-setting-mode in device-info
+// is taken at its word. Otherwise the media player is queried, since
+// Limited mode refuses it. Enabled and Permissive can't be told apart from the local
+// network, so both report PermissionEnabled.
+func (c *Client) ProbePermission(ctx context.Context) (PermissionMode, error) {
+	info, err := c.DeviceInfo(ctx)
+	if err != nil {
+		var limited *LimitedModeError
+		var status *StatusError
+		switch {
+		case errors.As(err, &limited):
+			return PermissionLimited, nil
+		case errors.As(err, &status) && status.Status == http.StatusForbidden:
+			return PermissionDisabled, nil
+		}
+		return PermissionUnknown, err
+	}
+	if mode := reportedPermission(info.EcpSettingMode); mode != PermissionUnknown {
+		return mode, nil
+	}
+	if _, err := c.MediaPlayer(ctx); err != nil {
+		var limited *LimitedModeError
+		if errors.As(err, &limited) {
+			return PermissionLimited, nil
+		}
+		return PermissionUnknown, err
+	}
+	return PermissionEnabled, nil
+}
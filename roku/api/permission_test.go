@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ProbePermission(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    PermissionMode
+	}{
+		{"ReportedByFirmware", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<device-info><ecp-setting-mode>Permissive</ecp-setting-mode></device-info>`)
+		}, PermissionPermissive},
+		{"ReportedDefault", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<device-info><ecp-setting-mode>default</ecp-setting-mode></device-info>`)
+		}, PermissionEnabled},
+		{"ReportedUnknown", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == EndpointMediaPlayer {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, "ECP command not allowed in Limited mode.")
+				return
+			}
+			fmt.Fprint(w, `<device-info><ecp-setting-mode>restricted</ecp-setting-mode></device-info>`)
+		}, PermissionLimited},
+		{"MediaPlayerAllowed", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == EndpointMediaPlayer {
+				fmt.Fprint(w, `<player error="false" state="close"/>`)
+				return
+			}
+			fmt.Fprint(w, `<device-info><model-name>Roku Ultra</model-name></device-info>`)
+		}, PermissionEnabled},
+		{"MediaPlayerRefused", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == EndpointMediaPlayer {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, "ECP command not allowed in Limited mode.")
+				return
+			}
+			fmt.Fprint(w, `<device-info><model-name>Roku Ultra</model-name></device-info>`)
+		}, PermissionLimited},
+		{"EverythingRefused", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}, PermissionDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := newMockServer(t, tt.handler)
+			defer server.Close()
+
+			mode, err := client.ProbePermission(context.Background())
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, mode)
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/grahamplata/roku-remote/roku"
+)
+
+// DefaultPrefix is the root of the state and command topics
+const DefaultPrefix = "roku"
+
+// DefaultDiscoveryPrefix is the topic Home Assistant listens on for discovery
+const DefaultDiscoveryPrefix = "homeassistant"
+
+// Payloads published to availability topics
+const (
+	Online  = "online"
+	Offline = "offline"
+)
+
+// commandQueue is how many commands a device holds while one is running,
+// beyond which commands are dropped
+const commandQueue = 16
+
+// Buttons are the keys exposed as Home Assistant buttons
+var Buttons = []string{
+	"home", "back", "up", "down", "left", "right", "select",
+	"play", "rev", "fwd", "replay", "info",
+	"volumeup", "volumedown", "mute", "poweron", "poweroff",
+}
+
+// Broker is the subset of an MQTT client the bridge needs
+type Broker interface {
+	// Publish sends payload to topic, retained for new subscribers if retain is set
+	Publish(topic string, retain bool, payload []byte) error
+	// Subscribe calls handler for every message received on topic. Handlers
+	// queue the command and return without waiting for the device, so they
+	// can run on the client's delivery goroutine.
+	Subscribe(topic string, handler func(topic string, payload []byte)) error
+}
+
+// Options configures a Bridge
+type Options struct {
+	// Prefix of the state and command topics, DefaultPrefix when empty
+	Prefix string
+	// DiscoveryPrefix for Home Assistant, DefaultDiscoveryPrefix when empty.
+	// Set Discovery to false to skip discovery entirely.
+	DiscoveryPrefix string
+	Discovery       bool
+	// Watch configures how often devices are polled for changes
+	Watch roku.WatchOptions
+	// Logger records command failures, discarded when nil
+	Logger *slog.Logger
+}
+
+// State is the device state published to <prefix>/<id>/state
+type State struct {
+	Power       string `json:"power"`
+	PowerMode   string `json:"power_mode,omitempty"`
+	AppID       string `json:"app_id,omitempty"`
+	App         string `json:"app,omitempty"`
+	PlayerState string `json:"player_state,omitempty"`
+}
+
+// Bridge publishes the state of Roku devices to MQTT and forwards commands
+// received on MQTT to the devices.
+//
+// For a device with id <id> it publishes:
+//
+//	<prefix>/<id>/availability   online or offline
+//	<prefix>/<id>/state          State as JSON
+//
+// and subscribes to:
+//
+//	<prefix>/<id>/keypress       a key name such as home or volumeup
+//	<prefix>/<id>/launch         an app id
+//	<prefix>/<id>/input          text to send to the device
+type Bridge struct {
+	broker  Broker
+	devices []*roku.Device
+	opts    Options
+	logger  *slog.Logger
+}
+
+// New creates a bridge between broker and devices
+func New(broker Broker, devices []*roku.Device, opts Options) *Bridge {
+	if opts.Prefix == "" {
+		opts.Prefix = DefaultPrefix
+	}
+	if opts.DiscoveryPrefix == "" {
+		opts.DiscoveryPrefix = DefaultDiscoveryPrefix
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	return &Bridge{broker: broker, devices: devices, opts: opts, logger: logger}
+}
+
+// StatusTopic is where the bridge reports itself online. Clients should set
+// their will message to Offline on this topic.
+func (b *Bridge) StatusTopic() string {
+	return b.opts.Prefix + "/status"
+}
+
+// Run bridges every device until ctx is cancelled. Devices that can't be
+// reached at startup are skipped and reported in the returned error only if
+// none could be bridged.
+func (b *Bridge) Run(ctx context.Context) error {
+	if err := b.broker.Publish(b.StatusTopic(), true, []byte(Online)); err != nil {
+		return fmt.Errorf("failed to publish bridge status: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	var errs []error
+	for _, device := range b.devices {
+		d, err := b.attach(ctx, device)
+		if err != nil {
+			b.logger.Warn("skipping device", "device", device.IP, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.run(ctx)
+		}()
+	}
+	if len(errs) == len(b.devices) && len(errs) > 0 {
+		return fmt.Errorf("no devices could be bridged: %w", errs[0])
+	}
+	wg.Wait()
+	return nil
+}
+
+// bridgedDevice is a device attached to the broker
+type bridgedDevice struct {
+	*Bridge
+	device *roku.Device
+	id     string
+	name   string
+
+	// commands run in order on the device's own goroutine, so a device that
+	// is slow to answer doesn't hold up the broker's delivery to the others
+	commands chan command
+
+	mu    sync.Mutex
+	state State
+}
+
+// command is a message received on one of a device's command topics
+type command struct {
+	name  string
+	value string
+	run   func(context.Context, string) error
+}
+
+// attach reads the device identity, publishes discovery and subscribes to
+// its command topics
+func (b *Bridge) attach(ctx context.Context, device *roku.Device) (*bridgedDevice, error) {
+	info, err := device.DeviceInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device info for %s: %w", device.IP, err)
+	}
+	d := &bridgedDevice{
+		Bridge:   b,
+		device:   device,
+		id:       DeviceID(info.SerialNumber, device.IP),
+		name:     info.FriendlyDeviceName,
+		commands: make(chan command, commandQueue),
+	}
+	if d.name == "" {
+		d.name = "Roku " + device.IP
+	}
+
+	if b.opts.Discovery {
+		for topic, payload := range d.discovery(info.ModelName, info.SoftwareVersion) {
+			data, err := json.Marshal(payload)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode discovery: %w", err)
+			}
+			if err := b.broker.Publish(topic, true, data); err != nil {
+				return nil, fmt.Errorf("failed to publish discovery: %w", err)
+			}
+		}
+	}
+
+	commands := map[string]func(context.Context, string) error{
+		"keypress": device.Action,
+		"launch":   device.Launch,
+		"input":    device.Client.Input,
+	}
+	for name, run := range commands {
+		err := b.broker.Subscribe(d.topic(name), func(topic string, payload []byte) {
+			cmd := command{name: name, value: strings.TrimSpace(string(payload)), run: run}
+			select {
+			case d.commands <- cmd:
+			default:
+				b.logger.Warn("dropping command, device is busy", "device", device.IP, "command", name, "value", cmd.value)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", d.topic(name), err)
+		}
+	}
+	return d, nil
+}
+
+// run publishes the initial state and then every change until ctx is
+// cancelled. The initial state comes from the watch's own first poll, so no
+// change can fall between it and the state changes are diffed against.
+func (d *bridgedDevice) run(ctx context.Context) {
+	go d.runCommands(ctx)
+	d.publishAvailability(Online)
+	opts := d.opts.Watch
+	opts.Initial = true
+	for event := range d.device.Watch(ctx, opts) {
+		d.apply(event)
+	}
+	d.publishAvailability(Offline)
+}
+
+// runCommands sends queued commands to the device until ctx is cancelled
+func (d *bridgedDevice) runCommands(ctx context.Context) {
+	for {
+		select {
+		case cmd := <-d.commands:
+			if err := cmd.run(ctx, cmd.value); err != nil {
+				d.logger.Warn("command failed", "device", d.device.IP, "command", cmd.name, "value", cmd.value, "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// apply updates the state from a watch event and publishes it
+func (d *bridgedDevice) apply(event roku.Event) {
+	d.mu.Lock()
+	switch event.Type {
+	case roku.EventError:
+		d.mu.Unlock()
+		d.publishAvailability(Offline)
+		return
+	case roku.EventInitial:
+		d.state = State{
+			Power:       powerState(event.State.PowerMode),
+			PowerMode:   event.State.PowerMode,
+			AppID:       event.State.App.ID,
+			App:         event.State.App.Name,
+			PlayerState: event.State.PlayerState,
+		}
+	case roku.EventPowerChanged:
+		d.state.PowerMode = event.To
+		d.state.Power = powerState(event.To)
+	case roku.EventAppChanged:
+		d.state.AppID, d.state.App = "", ""
+		if event.App != nil {
+			d.state.AppID, d.state.App = event.App.ID, event.App.Name
+		}
+	case roku.EventPlaybackStarted, roku.EventPaused, roku.EventStopped:
+		d.state.PlayerState = event.To
+	}
+	d.mu.Unlock()
+	d.publishAvailability(Online)
+	d.publishState()
+}
+
+func (d *bridgedDevice) publishState() {
+	d.mu.Lock()
+	data, err := json.Marshal(d.state)
+	d.mu.Unlock()
+	if err != nil {
+		return
+	}
+	if err := d.broker.Publish(d.topic("state"), true, data); err != nil {
+		d.logger.Warn("failed to publish state", "device", d.device.IP, "error", err)
+	}
+}
+
+func (d *bridgedDevice) publishAvailability(availability string) {
+	if err := d.broker.Publish(d.topic("availability"), true, []byte(availability)); err != nil {
+		d.logger.Warn("failed to publish availability", "device", d.device.IP, "error", err)
+	}
+}
+
+func (d *bridgedDevice) topic(name string) string {
+	return fmt.Sprintf("%s/%s/%s", d.opts.Prefix, d.id, name)
+}
+
+// discovery returns the Home Assistant discovery payloads keyed by topic.
+// The MQTT integration has no media_player platform, so each device is a set
+// of sensors for its state and buttons for its keys, grouped as one device.
+func (d *bridgedDevice) discovery(model, softwareVersion string) map[string]map[string]any {
+	device := map[string]any{
+		"identifiers":  []string{"roku_" + d.id},
+		"name":         d.name,
+		"manufacturer": "Roku",
+		"model":        model,
+		"sw_version":   softwareVersion,
+	}
+	availability := []map[string]string{
+		{"topic": d.StatusTopic()},
+		{"topic": d.topic("availability")},
+	}
+	entity := func(key, name string) map[string]any {
+		return map[string]any{
+			"name":              name,
+			"unique_id":         fmt.Sprintf("roku_%s_%s", d.id, key),
+			"object_id":         fmt.Sprintf("%s_%s", slug(d.name), key),
+			"device":            device,
+			"availability":      availability,
+			"availability_mode": "all",
+		}
+	}
+
+	payloads := make(map[string]map[string]any)
+	sensors := []struct{ key, name, template, icon string }{
+		{"power", "Power", "{{ value_json.power }}", "mdi:power"},
+		{"app", "Active app", "{{ value_json.app }}", "mdi:application"},
+		{"player_state", "Player state", "{{ value_json.player_state }}", "mdi:play-pause"},
+	}
+	for _, s := range sensors {
+		p := entity(s.key, s.name)
+		p["state_topic"] = d.topic("state")
+		p["value_template"] = s.template
+		p["icon"] = s.icon
+		payloads[fmt.Sprintf("%s/sensor/roku_%s/%s/config", d.opts.DiscoveryPrefix, d.id, s.key)] = p
+	}
+	for _, key := range Buttons {
+		p := entity(key, buttonName(key))
+		p["command_topic"] = d.topic("keypress")
+		p["payload_press"] = key
+		payloads[fmt.Sprintf("%s/button/roku_%s/%s/config", d.opts.DiscoveryPrefix, d.id, key)] = p
+	}
+	text := entity("input", "Text input")
+	text["command_topic"] = d.topic("input")
+	text["mode"] = "text"
+	payloads[fmt.Sprintf("%s/text/roku_%s/input/config", d.opts.DiscoveryPrefix, d.id)] = text
+	return payloads
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// DeviceID returns the topic-safe id for a device, its serial number where
+// known and otherwise its IP
+func DeviceID(serial, ip string) string {
+	if serial != "" {
+		return slug(serial)
+	}
+	return slug(ip)
+}
+
+func slug(s string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s), "_"), "_")
+}
+
+// powerState maps a device-info power mode to ON or OFF
+func powerState(mode string) string {
+	if mode == "" || mode == "PowerOn" {
+		return "ON"
+	}
+	return "OFF"
+}
+
+// buttonName returns a display name for a key
+func buttonName(key string) string {
+	names := map[string]string{
+		"rev":        "Rewind",
+		"fwd":        "Fast forward",
+		"volumeup":   "Volume up",
+		"volumedown": "Volume down",
+		"poweron":    "Power on",
+		"poweroff":   "Power off",
+	}
+	if name, ok := names[key]; ok {
+		return name
+	}
+	return strings.ToUpper(key[:1]) + key[1:]
+}
@@ -0,0 +1,203 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryBroker is an in-process broker stand-in that keeps retained messages
+// and delivers published messages to subscribers
+type memoryBroker struct {
+	mu       sync.Mutex
+	retained map[string][]byte
+	handlers map[string]func(string, []byte)
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{retained: make(map[string][]byte), handlers: make(map[string]func(string, []byte))}
+}
+
+func (b *memoryBroker) Publish(topic string, retain bool, payload []byte) error {
+	b.mu.Lock()
+	if retain {
+		b.retained[topic] = payload
+	}
+	handler := b.handlers[topic]
+	b.mu.Unlock()
+	if handler != nil {
+		handler(topic, payload)
+	}
+	return nil
+}
+
+func (b *memoryBroker) Subscribe(topic string, handler func(string, []byte)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = handler
+	return nil
+}
+
+func (b *memoryBroker) get(topic string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.retained[topic])
+}
+
+// customTransport redirects device requests to the test server
+type customTransport struct {
+	serverURL string
+}
+
+func (t *customTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Host = strings.TrimPrefix(t.serverURL, "http://")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestBridge_Run(t *testing.T) {
+	var mu sync.Mutex
+	app := `<app id="12">Netflix</app>`
+	var posts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case api.EndpointDeviceInfo:
+			fmt.Fprint(w, `<device-info><serial-number>YN00H5123456</serial-number><friendly-device-name>Living Room</friendly-device-name><model-name>Roku Ultra</model-name><power-mode>PowerOn</power-mode></device-info>`)
+		case api.EndpointActiveApp:
+			fmt.Fprintf(w, `<active-app>%s</active-app>`, app)
+		case api.EndpointMediaPlayer:
+			fmt.Fprint(w, `<player error="false" state="play"></player>`)
+		default:
+			body, _ := io.ReadAll(r.Body)
+			posts = append(posts, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+string(body)))
+		}
+	}))
+	defer server.Close()
+
+	device := &roku.Device{
+		IP:     "192.168.1.10",
+		Client: api.NewClient("192.168.1.10", &http.Client{Transport: &customTransport{serverURL: server.URL}}),
+	}
+	broker := newMemoryBroker()
+	b := New(broker, []*roku.Device{device}, Options{
+		Discovery: true,
+		Watch:     roku.WatchOptions{Interval: 20 * time.Millisecond},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx) }()
+
+	stateTopic := "roku/yn00h5123456/state"
+	readState := func() State {
+		var state State
+		_ = json.Unmarshal([]byte(broker.get(stateTopic)), &state)
+		return state
+	}
+	require.Eventually(t, func() bool { return readState().App == "Netflix" }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, State{Power: "ON", PowerMode: "PowerOn", AppID: "12", App: "Netflix", PlayerState: "play"}, readState())
+	assert.Equal(t, Online, broker.get("roku/status"))
+	assert.Equal(t, Online, broker.get("roku/yn00h5123456/availability"))
+
+	// Discovery
+	var button map[string]any
+	require.NoError(t, json.Unmarshal([]byte(broker.get("homeassistant/button/roku_yn00h5123456/home/config")), &button))
+	assert.Equal(t, "roku/yn00h5123456/keypress", button["command_topic"])
+	assert.Equal(t, "home", button["payload_press"])
+	assert.Equal(t, "roku_yn00h5123456_home", button["unique_id"])
+	var sensor map[string]any
+	require.NoError(t, json.Unmarshal([]byte(broker.get("homeassistant/sensor/roku_yn00h5123456/app/config")), &sensor))
+	assert.Equal(t, stateTopic, sensor["state_topic"])
+	assert.Equal(t, "Living Room", sensor["device"].(map[string]any)["name"])
+
+	// State changes are published
+	mu.Lock()
+	app = `<app id="13">YouTube</app>`
+	mu.Unlock()
+	require.Eventually(t, func() bool { return readState().App == "YouTube" }, 2*time.Second, 10*time.Millisecond)
+
+	// Commands are forwarded to the device
+	require.NoError(t, broker.Publish("roku/yn00h5123456/keypress", false, []byte("home")))
+	require.NoError(t, broker.Publish("roku/yn00h5123456/launch", false, []byte("12")))
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return assert.ObjectsAreEqual([]string{"POST /keypress/Home", "POST /launch id=12"}, posts)
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, Offline, broker.get("roku/yn00h5123456/availability"))
+}
+
+func TestBridge_SlowDevice(t *testing.T) {
+	// The first device never answers a keypress, and the broker delivers
+	// messages one at a time like paho does
+	release := make(chan struct{})
+	defer close(release)
+	newDevice := func(ip, serial string, keypress http.HandlerFunc) *roku.Device {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == api.EndpointDeviceInfo {
+				fmt.Fprintf(w, `<device-info><serial-number>%s</serial-number><power-mode>PowerOn</power-mode></device-info>`, serial)
+				return
+			}
+			if strings.HasPrefix(r.URL.Path, api.EndpointKeypress) {
+				keypress(w, r)
+			}
+		}))
+		t.Cleanup(server.Close)
+		return &roku.Device{
+			IP:     ip,
+			Client: api.NewClient(ip, &http.Client{Transport: &customTransport{serverURL: server.URL}}),
+		}
+	}
+	var pressed sync.WaitGroup
+	pressed.Add(1)
+	slow := newDevice("192.168.1.10", "SLOW", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	fast := newDevice("192.168.1.11", "FAST", func(w http.ResponseWriter, r *http.Request) {
+		pressed.Done()
+	})
+
+	broker := newMemoryBroker()
+	b := New(broker, []*roku.Device{slow, fast}, Options{Watch: roku.WatchOptions{Interval: time.Hour}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = b.Run(ctx) }()
+	require.Eventually(t, func() bool { return broker.get("roku/fast/availability") == Online }, 2*time.Second, 10*time.Millisecond)
+
+	go func() {
+		_ = broker.Publish("roku/slow/keypress", false, []byte("home"))
+		_ = broker.Publish("roku/fast/keypress", false, []byte("home"))
+	}()
+	done := make(chan struct{})
+	go func() {
+		pressed.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("keypress for the second device was held up by the first")
+	}
+}
+
+func TestDeviceID(t *testing.T) {
+	assert.Equal(t, "yn00h5123456", DeviceID("YN00H5123456", "192.168.1.10"))
+	assert.Equal(t, "192_168_1_10", DeviceID("", "192.168.1.10"))
+}
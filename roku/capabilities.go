@@ -0,0 +1,149 @@
+package roku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku/api"
+)
+
+// ErrUnsupported is returned for actions the device model doesn't support.
+// Roku devices accept these keypresses and silently ignore them.
+var ErrUnsupported = errors.New("not supported")
+
+// Capabilities describes what a device model can do, read from device-info
+type Capabilities struct {
+	// Model is the friendly model name, such as Roku Ultra
+	Model string
+	// TV is set for Roku TVs, which have power, volume, channel and input keys
+	TV bool
+	// Stick is set for streaming sticks
+	Stick bool
+	// Audio is set for Roku soundbars and Streambars, which have power and
+	// volume keys but no inputs
+	Audio bool
+	// Suspend is set for devices that can be turned off and on over ECP
+	Suspend bool
+	// FindRemote is set when the device has a remote that can play a sound
+	FindRemote bool
+	// PrivateListening is set when the device can play audio through the
+	// mobile app
+	PrivateListening bool
+}
+
+// NewCapabilities reads the capabilities from device-info
+func NewCapabilities(info *api.DeviceInfo) Capabilities {
+	model := info.FriendlyModelName
+	if model == "" {
+		model = info.ModelName
+	}
+	lower := strings.ToLower(model)
+	return Capabilities{
+		Model:            model,
+		TV:               info.IsTv,
+		Stick:            info.IsStick,
+		Audio:            !info.IsTv && (strings.Contains(lower, "soundbar") || strings.Contains(lower, "streambar")),
+		Suspend:          info.SupportsSuspend,
+		FindRemote:       info.SupportsFindRemote && info.FindRemoteIsPossible,
+		PrivateListening: info.SupportsPrivateListening,
+	}
+}
+
+// Check returns an ErrUnsupported error explaining why the device can't
+// handle the key, or nil when it can
+func (c Capabilities) Check(key api.Key) error {
+	var reason string
+	switch key {
+	case api.KeyFindRemote:
+		if !c.FindRemote {
+			reason = "its remote can't play a sound"
+		}
+	case api.KeyVolumeUp, api.KeyVolumeDown, api.KeyVolumeMute:
+		if !c.TV && !c.Audio {
+			reason = "only Roku TVs and audio devices control the volume"
+		}
+	case api.KeyPower, api.KeyPowerOn, api.KeyPowerOff:
+		if !c.TV && !c.Audio && !c.Suspend {
+			reason = "it can't be turned off over ECP"
+		}
+	default:
+		if spec, ok := key.Spec(); ok && spec.NeedsTV && !c.TV {
+			reason = "only Roku TVs have tuner, input, channel and sleep keys"
+		}
+	}
+	if reason == "" {
+		return nil
+	}
+	name := string(key)
+	if spec, ok := key.Spec(); ok {
+		name = spec.Name
+	}
+	return fmt.Errorf("%s is %w by %s: %s", name, ErrUnsupported, c.Model, reason)
+}
+
+// Supports reports whether the device can handle the key
+func (c Capabilities) Supports(key api.Key) bool {
+	return c.Check(key) == nil
+}
+
+// CheckInputs returns an ErrUnsupported error for devices without inputs
+func (c Capabilities) CheckInputs() error {
+	if c.TV {
+		return nil
+	}
+	return fmt.Errorf("inputs are %w by %s: only Roku TVs have inputs", ErrUnsupported, c.Model)
+}
+
+// deviceCache holds what has been learned about a device so it isn't
+// queried again for every command. It is kept behind a pointer so Device
+// values can still be copied.
+type deviceCache struct {
+	mu   sync.Mutex
+	caps *Capabilities
+	// permission was probed at permissionAt, and is probed again once it is
+	// older than PermissionTTL since the setting can change at any time
+	permission   api.PermissionMode
+	permissionAt time.Time
+}
+
+// Capabilities reads what the device model supports from device-info. The
+// result is cached for devices created with NewDevice, since it doesn't
+// change while the device is running.
+func (d *Device) Capabilities(ctx context.Context) (Capabilities, error) {
+	if d.cache == nil {
+		return d.fetchCapabilities(ctx)
+	}
+	d.cache.mu.Lock()
+	defer d.cache.mu.Unlock()
+	if d.cache.caps != nil {
+		return *d.cache.caps, nil
+	}
+	caps, err := d.fetchCapabilities(ctx)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	d.cache.caps = &caps
+	return caps, nil
+}
+
+func (d *Device) fetchCapabilities(ctx context.Context) (Capabilities, error) {
+	info, err := d.DeviceInfo(ctx)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	return NewCapabilities(info), nil
+}
+
+// CheckKey returns an ErrUnsupported error when the device model can't
+// handle the key
+func (d *Device) CheckKey(ctx context.Context, key api.Key) error {
+	caps, err := d.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+	return caps.Check(key)
+}
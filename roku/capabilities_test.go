@@ -0,0 +1,64 @@
+package roku
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities_Check(t *testing.T) {
+	tv := NewCapabilities(&api.DeviceInfo{FriendlyModelName: "Roku TV", IsTv: true, SupportsSuspend: true, SupportsFindRemote: true, FindRemoteIsPossible: true})
+	player := NewCapabilities(&api.DeviceInfo{ModelName: "Roku Ultra", SupportsFindRemote: true})
+	soundbar := NewCapabilities(&api.DeviceInfo{FriendlyModelName: "Roku Streambar"})
+
+	for _, spec := range api.Keys {
+		assert.True(t, tv.Supports(spec.Key), spec.Name)
+	}
+
+	assert.Equal(t, "Roku Ultra", player.Model)
+	assert.True(t, player.Supports(api.KeyHome))
+	assert.False(t, player.Supports(api.KeyVolumeUp))
+	assert.False(t, player.Supports(api.KeyPowerOff))
+	assert.False(t, player.Supports(api.KeyFindRemote))
+	err := player.Check(api.KeyInputHDMI1)
+	assert.ErrorIs(t, err, ErrUnsupported)
+	assert.EqualError(t, err, "hdmi1 is not supported by Roku Ultra: only Roku TVs have tuner, input, channel and sleep keys")
+	assert.ErrorIs(t, player.CheckInputs(), ErrUnsupported)
+
+	assert.True(t, soundbar.Audio)
+	assert.True(t, soundbar.Supports(api.KeyVolumeMute))
+	assert.True(t, soundbar.Supports(api.KeyPowerOff))
+	assert.False(t, soundbar.Supports(api.KeyInputTuner))
+}
+
+func TestDevice_Capabilities(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<device-info>
+	<friendly-model-name>Roku Express</friendly-model-name>
+	<is-tv>false</is-tv>
+	<is-stick>true</is-stick>
+	<supports-find-remote>false</supports-find-remote>
+</device-info>`))
+	}))
+	defer server.Close()
+
+	device := createTestDevice(server)
+	device.cache = &deviceCache{}
+	ctx := context.Background()
+
+	caps, err := device.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Capabilities{Model: "Roku Express", Stick: true}, caps)
+
+	assert.ErrorIs(t, device.CheckKey(ctx, api.KeyFindRemote), ErrUnsupported)
+	assert.NoError(t, device.CheckKey(ctx, api.KeySelect))
+	assert.Equal(t, 1, requests)
+}
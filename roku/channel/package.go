@@ -0,0 +1,172 @@
+package channel
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ManifestFile must be present at the root of every channel
+const ManifestFile = "manifest"
+
+// IgnoreFile lists paths to leave out of the package, one pattern per line
+const IgnoreFile = ".rokuignore"
+
+// defaultIgnores are never packaged
+var defaultIgnores = []string{".git/", ".DS_Store", IgnoreFile}
+
+// Manifest holds the key=value pairs from a channel's manifest file
+type Manifest map[string]string
+
+// Title returns the channel title from the manifest
+func (m Manifest) Title() string {
+	return m["title"]
+}
+
+// Version returns the major.minor.build version from the manifest
+func (m Manifest) Version() string {
+	return fmt.Sprintf("%s.%s.%s", m["major_version"], m["minor_version"], m["build_version"])
+}
+
+// Package is a zipped BrightScript channel ready to sideload
+type Package struct {
+	Manifest Manifest
+	Files    []string
+	Data     []byte
+}
+
+// ReadManifest parses the manifest at the root of dir
+func ReadManifest(dir string) (Manifest, error) {
+	f, err := os.Open(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("channel %s has no manifest: %w", dir, err)
+	}
+	defer f.Close()
+
+	manifest := make(Manifest)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		manifest[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// Build zips the channel in dir, skipping anything matched by the ignore file
+func Build(dir string) (*Package, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	ignores, err := readIgnores(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	var files []string
+	err = filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if ignored(rel, entry.IsDir(), ignores) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		w, err := zw.Create(rel)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := io.Copy(w, f); err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error packaging channel %s: %w", dir, err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("error packaging channel %s: %w", dir, err)
+	}
+	return &Package{Manifest: manifest, Files: files, Data: buf.Bytes()}, nil
+}
+
+// readIgnores loads the ignore patterns for dir, including the defaults
+func readIgnores(dir string) ([]string, error) {
+	patterns := append([]string{}, defaultIgnores...)
+	data, err := os.ReadFile(filepath.Join(dir, IgnoreFile))
+	if os.IsNotExist(err) {
+		return patterns, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", IgnoreFile, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// ignored reports whether rel matches any pattern. Patterns ending in "/" only
+// match directories, patterns containing "/" match the full relative path and
+// anything else matches the base name at any depth.
+func ignored(rel string, isDir bool, patterns []string) bool {
+	for _, pattern := range patterns {
+		dirOnly := strings.HasSuffix(pattern, "/")
+		pattern = strings.TrimSuffix(pattern, "/")
+		if dirOnly && !isDir {
+			continue
+		}
+		target := path.Base(rel)
+		if strings.Contains(pattern, "/") {
+			target = rel
+			pattern = strings.TrimPrefix(pattern, "/")
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
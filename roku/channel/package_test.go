@@ -0,0 +1,76 @@
+package channel
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"manifest":              "title=Test Channel\nmajor_version=1\nminor_version=2\nbuild_version=3\n",
+		"source/main.brs":       "sub main()\nend sub\n",
+		"components/Main.xml":   "<component/>",
+		"images/icon.png":       "png",
+		"images/raw/icon.psd":   "psd",
+		"notes.md":              "notes",
+		".git/HEAD":             "ref",
+		".rokuignore":           "# comments are skipped\n*.md\nimages/raw/\n",
+		"source/test/tests.brs": "sub test()\nend sub\n",
+	})
+
+	pkg, err := Build(dir)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Test Channel", pkg.Manifest.Title())
+	assert.Equal(t, "1.2.3", pkg.Manifest.Version())
+
+	zr, err := zip.NewReader(bytes.NewReader(pkg.Data), int64(len(pkg.Data)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"components/Main.xml", "images/icon.png", "manifest", "source/main.brs", "source/test/tests.brs"}, names)
+	assert.ElementsMatch(t, names, pkg.Files)
+}
+
+func TestBuild_NoManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"source/main.brs": ""})
+
+	pkg, err := Build(dir)
+
+	assert.Error(t, err)
+	assert.Nil(t, pkg)
+	assert.Contains(t, err.Error(), "no manifest")
+}
+
+func TestIgnored(t *testing.T) {
+	patterns := []string{"*.md", "out/", "/source/debug.brs"}
+
+	assert.True(t, ignored("README.md", false, patterns))
+	assert.True(t, ignored("docs/guide.md", false, patterns))
+	assert.True(t, ignored("out", true, patterns))
+	assert.False(t, ignored("out", false, patterns))
+	assert.True(t, ignored("source/debug.brs", false, patterns))
+	assert.False(t, ignored("lib/source/debug.brs", false, patterns))
+	assert.False(t, ignored("source/main.brs", false, patterns))
+}
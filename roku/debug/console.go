@@ -0,0 +1,244 @@
+package debug
+
+/*
+Roku Docs
+BrightScript debug console
+https://developer.roku.com/docs/developer-program/debugging/debugging-channels.md
+*/
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Port is the BrightScript debug console port on dev-mode devices
+const Port = 8085
+
+// DefaultReconnectDelay is how long to wait before redialing a dropped console
+const DefaultReconnectDelay = time.Second
+
+// promptSuffix ends the debugger prompt, which is not followed by a newline
+const promptSuffix = "Debugger> "
+
+// Kind classifies a console line for highlighting
+type Kind int
+
+const (
+	KindInfo Kind = iota
+	KindWarning
+	KindError
+	KindBacktrace
+	KindDebugger
+	// KindStatus lines are generated locally for connects and disconnects
+	KindStatus
+)
+
+// String returns a short lowercase name for the kind
+func (k Kind) String() string {
+	switch k {
+	case KindWarning:
+		return "warning"
+	case KindError:
+		return "error"
+	case KindBacktrace:
+		return "backtrace"
+	case KindDebugger:
+		return "debugger"
+	case KindStatus:
+		return "status"
+	default:
+		return "info"
+	}
+}
+
+// Line is a single line of console output
+type Line struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+	Kind Kind      `json:"kind"`
+}
+
+// String renders the line with a timestamp, as written to session files
+func (l Line) String() string {
+	return fmt.Sprintf("%s %s", l.Time.Format("15:04:05.000"), l.Text)
+}
+
+var backtracePattern = regexp.MustCompile(`^#\d+\s`)
+
+// Classify determines the kind of a line of console output
+func Classify(text string) Kind {
+	lower := strings.ToLower(text)
+	switch {
+	case strings.HasSuffix(text, promptSuffix), strings.Contains(text, "Micro Debugger"):
+		return KindDebugger
+	case strings.Contains(lower, "runtime error"), strings.Contains(lower, "syntax error"),
+		strings.Contains(lower, "crash"), strings.Contains(text, "ERROR"):
+		return KindError
+	case strings.Contains(lower, "warning"):
+		return KindWarning
+	case backtracePattern.MatchString(strings.TrimSpace(text)):
+		return KindBacktrace
+	default:
+		return KindInfo
+	}
+}
+
+// Console is a client for the BrightScript debug console. It reconnects
+// automatically when the device drops the connection, which happens when the
+// channel is restarted or replaced.
+type Console struct {
+	// addr is the host:port of the console
+	addr string
+	// ReconnectDelay between dial attempts after the connection drops
+	ReconnectDelay time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewConsole creates a console client for the device at ip
+func NewConsole(ip string) *Console {
+	return &Console{
+		addr:           net.JoinHostPort(ip, fmt.Sprint(Port)),
+		ReconnectDelay: DefaultReconnectDelay,
+	}
+}
+
+// Stream connects to the console and returns its output. Dropped connections
+// are redialed until ctx is cancelled, at which point the channel is closed.
+func (c *Console) Stream(ctx context.Context) <-chan Line {
+	lines := make(chan Line, 64)
+	go func() {
+		defer close(lines)
+		emit := func(text string, kind Kind) bool {
+			select {
+			case lines <- Line{Time: time.Now(), Text: text, Kind: kind}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// waiting is set once the failed dial has been reported, so retries
+		// don't repeat it until the console has connected again
+		waiting := false
+		for ctx.Err() == nil {
+			conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", c.addr)
+			if err != nil {
+				if !waiting && !emit(fmt.Sprintf("waiting for console at %s: %v", c.addr, err), KindStatus) {
+					return
+				}
+				waiting = true
+				if !c.wait(ctx) {
+					return
+				}
+				continue
+			}
+			waiting = false
+			c.setConn(conn)
+			if !emit(fmt.Sprintf("connected to %s", c.addr), KindStatus) {
+				conn.Close()
+				return
+			}
+
+			err = c.read(ctx, conn, emit)
+			c.setConn(nil)
+			conn.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			if !emit(fmt.Sprintf("disconnected: %v, reconnecting", err), KindStatus) {
+				return
+			}
+			if !c.wait(ctx) {
+				return
+			}
+		}
+	}()
+	return lines
+}
+
+// read emits complete lines from conn, plus the debugger prompt which has no
+// trailing newline, until the connection fails
+func (c *Console) read(ctx context.Context, conn net.Conn, emit func(string, Kind) bool) error {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var pending []byte
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			pending = append(pending, buf[:n]...)
+			for {
+				i := bytes.IndexByte(pending, '\n')
+				if i < 0 {
+					break
+				}
+				text := strings.TrimRight(string(pending[:i]), "\r")
+				pending = pending[i+1:]
+				if !emit(text, Classify(text)) {
+					return ctx.Err()
+				}
+			}
+			if bytes.HasSuffix(pending, []byte(promptSuffix)) {
+				text := string(pending)
+				pending = nil
+				if !emit(text, KindDebugger) {
+					return ctx.Err()
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Console) wait(ctx context.Context) bool {
+	delay := c.ReconnectDelay
+	if delay <= 0 {
+		delay = DefaultReconnectDelay
+	}
+	select {
+	case <-time.After(delay):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (c *Console) setConn(conn net.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn = conn
+}
+
+// Send writes a command to the console followed by a newline
+func (c *Console) Send(command string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return fmt.Errorf("debug console at %s is not connected", c.addr)
+	}
+	_, err := fmt.Fprintf(c.conn, "%s\r\n", command)
+	return err
+}
+
+// Backtrace prints the call stack of the stopped channel
+func (c *Console) Backtrace() error { return c.Send("bt") }
+
+// Variables prints the local variables in the current scope
+func (c *Console) Variables() error { return c.Send("var") }
+
+// Continue resumes execution
+func (c *Console) Continue() error { return c.Send("cont") }
+
+// Step executes one statement
+func (c *Console) Step() error { return c.Send("step") }
@@ -0,0 +1,129 @@
+package debug
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		text string
+		kind Kind
+	}{
+		{"------ Running dev 'Test' main ------", KindInfo},
+		{"BRIGHTSCRIPT: WARNING: unused variable 'x'", KindWarning},
+		{"Type Mismatch. (runtime error &h18) in pkg:/source/main.brs(12)", KindError},
+		{"#1  Function main() As Void", KindBacktrace},
+		{"Brightscript Debugger> ", KindDebugger},
+		{"BrightScript Micro Debugger.", KindDebugger},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.kind, Classify(tt.text), tt.text)
+	}
+}
+
+func TestConsole_Stream(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	commands := make(chan string, 1)
+	go func() {
+		// First session: log output, a prompt, then a dropped connection
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		fmt.Fprint(conn, "------ Running dev 'Test' main ------\r\nruntime error in main.brs(3)\r\nBrightscript Debugger> ")
+		command, _ := bufio.NewReader(conn).ReadString('\n')
+		commands <- command
+		fmt.Fprint(conn, "#0  Function main() As Void\r\n")
+		conn.Close()
+
+		// Second session after the channel restarts
+		conn, err = listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(conn, "------ Running dev 'Test' main ------\r\n")
+		time.Sleep(time.Second)
+	}()
+
+	console := &Console{addr: listener.Addr().String(), ReconnectDelay: 10 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lines := console.Stream(ctx)
+
+	next := func() Line {
+		t.Helper()
+		line, ok := <-lines
+		require.True(t, ok, "stream closed early")
+		return line
+	}
+
+	assert.Equal(t, KindStatus, next().Kind)
+	assert.Equal(t, "------ Running dev 'Test' main ------", next().Text)
+	assert.Equal(t, KindError, next().Kind)
+	prompt := next()
+	assert.Equal(t, KindDebugger, prompt.Kind)
+	assert.Equal(t, "Brightscript Debugger> ", prompt.Text)
+
+	require.NoError(t, console.Backtrace())
+	assert.Equal(t, "bt\r\n", <-commands)
+	assert.Equal(t, KindBacktrace, next().Kind)
+
+	disconnected := next()
+	assert.Equal(t, KindStatus, disconnected.Kind)
+	assert.Contains(t, disconnected.Text, "reconnecting")
+	assert.Equal(t, KindStatus, next().Kind)
+	assert.Equal(t, "------ Running dev 'Test' main ------", next().Text)
+
+	cancel()
+	for range lines {
+	}
+}
+
+func TestConsole_StreamWaiting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	console := &Console{addr: addr, ReconnectDelay: 10 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lines := console.Stream(ctx)
+
+	waiting := <-lines
+	assert.Equal(t, KindStatus, waiting.Kind)
+	assert.Contains(t, waiting.Text, "waiting for console at "+addr)
+
+	// Later failed dials are not reported again
+	select {
+	case line := <-lines:
+		t.Fatalf("unexpected line %q", line.Text)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	for range lines {
+	}
+}
+
+func TestConsole_SendNotConnected(t *testing.T) {
+	console := NewConsole("127.0.0.1")
+
+	err := console.Continue()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not connected")
+}
@@ -2,7 +2,12 @@ package roku
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/grahamplata/roku-remote/roku/api"
 )
@@ -13,14 +18,70 @@ type Device struct {
 	IP string `yaml:"ip"`
 	// Client is an HTTP client used to communicate with the Roku device
 	Client *api.Client
+	// Session is an optional ECP-2 session, preferred over HTTP while it is open
+	Session *api.ECP2Session `yaml:"-"`
+	// Dev is the developer installer client, set by EnableDeveloper
+	Dev *api.DevClient `yaml:"-"`
+
+	cache *deviceCache
+}
+
+// ErrAppNotFound is returned by FindApp when no installed app matches
+var ErrAppNotFound = errors.New("app is not installed")
+
+// DefaultKeyPause is the delay between repeated keypresses, long enough for
+// TVs and soundbars to register each one
+const DefaultKeyPause = 250 * time.Millisecond
+
+// DefaultVolumeSteps is the number of volumedown presses that takes a Roku
+// TV from full volume to zero
+const DefaultVolumeSteps = 100
+
+// Option configures devices created with NewDevice and Find
+type Option func(*options)
+
+type options struct {
+	logger    *slog.Logger
+	transport http.RoundTripper
+}
+
+// WithLogger traces ECP requests, and discovery in Find, to logger. Output
+// is discarded without it.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithTransport sends ECP requests through transport, such as an
+// api.Recorder or api.Replayer. http.DefaultTransport is used without it.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.logger == nil {
+		o.logger = slog.New(slog.DiscardHandler)
+	}
+	return o
 }
 
 // NewDevice creates a new Roku Device instance
-func NewDevice(ip string) *Device {
-	httpClient := &http.Client{Timeout: api.DefaultTimeout}
+func NewDevice(ip string, opts ...Option) *Device {
+	o := newOptions(opts)
+	httpClient := &http.Client{Timeout: api.DefaultTimeout, Transport: o.transport}
+	client := api.NewClient(ip, httpClient)
+	client.SetLogger(o.logger)
 	return &Device{
 		IP:     ip,
-		Client: api.NewClient(ip, httpClient),
+		Client: client,
+		cache:  &deviceCache{},
 	}
 }
 
@@ -29,14 +90,182 @@ func (d *Device) Info(ctx context.Context) (*api.Info, error) {
 	return d.Client.Info(ctx)
 }
 
-// DeviceInfo retrieves detailed information about the Roku device
+// DeviceInfo retrieves detailed information about the Roku device. An open
+// ECP-2 session is used first, falling back to HTTP if it fails.
 func (d *Device) DeviceInfo(ctx context.Context) (*api.DeviceInfo, error) {
+	if d.sessionActive() {
+		if info, err := d.Session.DeviceInfo(ctx); err == nil {
+			return info, nil
+		}
+	}
 	return d.Client.DeviceInfo(ctx)
 }
 
-// Action issues a remote input action to the Roku device with retries
+// SGNodesAll retrieves every SceneGraph node in the running dev channel
+func (d *Device) SGNodesAll(ctx context.Context) (*api.SGNodes, error) {
+	return d.Client.SGNodesAll(ctx)
+}
+
+// SGNodesRoots retrieves the root SceneGraph nodes of the running dev channel
+func (d *Device) SGNodesRoots(ctx context.Context) (*api.SGNodes, error) {
+	return d.Client.SGNodesRoots(ctx)
+}
+
+// SGNode retrieves a SceneGraph node by id
+func (d *Device) SGNode(ctx context.Context, nodeID string) (*api.SGNodes, error) {
+	return d.Client.SGNode(ctx, nodeID)
+}
+
+// ChannelPerf samples the CPU and memory usage of a channel
+func (d *Device) ChannelPerf(ctx context.Context, appID string) (*api.ChannelPerf, error) {
+	return d.Client.ChannelPerf(ctx, appID)
+}
+
+// Registry retrieves the persistent registry of a channel
+func (d *Device) Registry(ctx context.Context, appID string) (*api.Registry, error) {
+	return d.Client.Registry(ctx, appID)
+}
+
+// Bitmaps retrieves the texture allocations of a channel
+func (d *Device) Bitmaps(ctx context.Context, appID string) (*api.Bitmaps, error) {
+	return d.Client.Bitmaps(ctx, appID)
+}
+
+// FrameRate retrieves the current graphics frame rate
+func (d *Device) FrameRate(ctx context.Context) (*api.FrameRate, error) {
+	return d.Client.FrameRate(ctx)
+}
+
+// ConnectECP2 opens an ECP-2 session to the device. If the device does not
+// support ECP-2 the error is returned and the device keeps using plain ECP.
+func (d *Device) ConnectECP2(ctx context.Context) error {
+	session, err := api.DialECP2(ctx, d.IP, nil)
+	if err != nil {
+		return err
+	}
+	d.Session = session
+	return nil
+}
+
+// EnableDeveloper configures access to the developer installer on port 80
+// using the password chosen when developer mode was enabled
+func (d *Device) EnableDeveloper(password string) {
+	d.Dev = api.NewDevClient(d.IP, password, nil)
+}
+
+// developer returns the installer client or an error if it isn't configured
+func (d *Device) developer() (*api.DevClient, error) {
+	if d.Dev == nil {
+		return nil, fmt.Errorf("developer access is not configured for device %s", d.IP)
+	}
+	return d.Dev, nil
+}
+
+// Screenshot captures the screen of the running sideloaded channel
+func (d *Device) Screenshot(ctx context.Context) (*api.Screenshot, error) {
+	dev, err := d.developer()
+	if err != nil {
+		return nil, err
+	}
+	return dev.Screenshot(ctx)
+}
+
+// Sideload installs a zipped channel through the developer installer
+func (d *Device) Sideload(ctx context.Context, archive []byte) (*api.InstallerResult, error) {
+	dev, err := d.developer()
+	if err != nil {
+		return nil, err
+	}
+	return dev.Install(ctx, archive)
+}
+
+// DeleteSideload removes the sideloaded channel
+func (d *Device) DeleteSideload(ctx context.Context) (*api.InstallerResult, error) {
+	dev, err := d.developer()
+	if err != nil {
+		return nil, err
+	}
+	return dev.Delete(ctx)
+}
+
+// Rekey sets the device's signing key from a signed package
+func (d *Device) Rekey(ctx context.Context, signedPackage []byte, password string) (*api.InstallerResult, error) {
+	dev, err := d.developer()
+	if err != nil {
+		return nil, err
+	}
+	return dev.Rekey(ctx, signedPackage, password)
+}
+
+// Close ends the ECP-2 session, if one is open
+func (d *Device) Close() error {
+	if d.Session == nil {
+		return nil
+	}
+	err := d.Session.Close()
+	d.Session = nil
+	return err
+}
+
+// sessionActive reports whether an ECP-2 session is open and usable
+func (d *Device) sessionActive() bool {
+	return d.Session != nil && d.Session.Err() == nil
+}
+
+// Action issues a remote input action to the Roku device with retries. The
+// action is a key name, alias or value accepted by api.ParseKey.
 func (d *Device) Action(ctx context.Context, action string) error {
-	return d.Client.Keypress(ctx, action)
+	key, err := api.ParseKey(action)
+	if err != nil {
+		return fmt.Errorf("%w for device %s", err, d.IP)
+	}
+	return d.Keypress(ctx, key)
+}
+
+// Keypress sends a key to the Roku device with retries. An open ECP-2
+// session is used first, falling back to HTTP only when the key never reached
+// the device or the session dropped, so a slow reply can't press it twice.
+func (d *Device) Keypress(ctx context.Context, key api.Key) error {
+	if d.sessionActive() {
+		err := d.Session.Keypress(ctx, key)
+		if err == nil || (!errors.Is(err, api.ErrNotSent) && d.sessionActive()) {
+			return err
+		}
+	}
+	return d.Client.Keypress(ctx, key)
+}
+
+// Press sends a key n times, waiting pause between presses
+func (d *Device) Press(ctx context.Context, key api.Key, n int, pause time.Duration) error {
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			timer := time.NewTimer(pause)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+		if err := d.Keypress(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetVolume sets an absolute volume level. ECP can only step the volume, so
+// volumedown is pressed steps times to reach zero from any level, then
+// volumeup is pressed level times. steps is calibrated per device as the
+// number of presses from full volume to zero.
+func (d *Device) SetVolume(ctx context.Context, level, steps int, pause time.Duration) error {
+	if level < 0 || level > steps {
+		return fmt.Errorf("volume %d is out of range 0 to %d", level, steps)
+	}
+	if err := d.Press(ctx, api.KeyVolumeDown, steps, pause); err != nil {
+		return err
+	}
+	return d.Press(ctx, api.KeyVolumeUp, level, pause)
 }
 
 // Launch an application on the Roku device
@@ -44,14 +273,26 @@ func (d *Device) Launch(ctx context.Context, appID string) error {
 	return d.Client.Launch(ctx, appID)
 }
 
-// Player retrieves the current media player state
+// Search opens search results on the Roku device, or plays the content
+// when opts.Launch is set
+func (d *Device) Search(ctx context.Context, opts api.SearchOptions) error {
+	return d.Client.Search(ctx, opts)
+}
+
+// Player retrieves the current media player state. An open ECP-2 session is
+// used first, falling back to HTTP if it fails.
 func (d *Device) Player(ctx context.Context) (*api.Player, error) {
+	if d.sessionActive() {
+		if player, err := d.Session.MediaPlayer(ctx); err == nil {
+			return player, nil
+		}
+	}
 	return d.Client.MediaPlayer(ctx)
 }
 
 // Describe retrieves and formats device details
 func (d *Device) Describe(ctx context.Context) (*api.DeviceInfo, error) {
-	return d.Client.DeviceInfo(ctx)
+	return d.DeviceInfo(ctx)
 }
 
 // Install installs an application on the Roku device
@@ -64,6 +305,20 @@ func (d *Device) FetchInstalledApps(ctx context.Context) (*api.Apps, error) {
 	return d.Client.Apps(ctx)
 }
 
+// FindApp looks up an installed app by id or case-insensitive name
+func (d *Device) FindApp(ctx context.Context, idOrName string) (*api.App, error) {
+	apps, err := d.FetchInstalledApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, app := range apps.Apps {
+		if strings.EqualFold(app.ID, idOrName) || strings.EqualFold(app.Name, idOrName) {
+			return &app, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrAppNotFound, idOrName)
+}
+
 // ActiveApp retrieves the currently active application
 func (d *Device) ActiveApp(ctx context.Context) (*api.ActiveApp, error) {
 	return d.Client.ActiveApp(ctx)
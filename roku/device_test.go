@@ -2,10 +2,13 @@ package roku
 
 import (
 	"context"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/grahamplata/roku-remote/roku/api"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +36,17 @@ func TestNewDevice(t *testing.T) {
 	assert.NotNil(t, device.Client)
 }
 
+func TestNewOptions(t *testing.T) {
+	assert.NotNil(t, newOptions(nil).logger)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	assert.Same(t, logger, newOptions([]Option{WithLogger(logger)}).logger)
+
+	assert.Nil(t, newOptions(nil).transport)
+	transport := &http.Transport{}
+	assert.Same(t, transport, newOptions([]Option{WithTransport(transport)}).transport)
+}
+
 func TestDevice_Info(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -88,6 +102,30 @@ func TestDevice_Action(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDevice_SetVolume(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, strings.TrimPrefix(r.URL.Path, "/keypress/"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	device := createTestDevice(server)
+
+	err := device.SetVolume(context.Background(), 2, 3, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"VolumeDown", "VolumeDown", "VolumeDown", "VolumeUp", "VolumeUp"}, keys)
+
+	err = device.SetVolume(context.Background(), 4, 3, time.Millisecond)
+	assert.EqualError(t, err, "volume 4 is out of range 0 to 3")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	keys = nil
+	err = device.Press(ctx, api.KeyVolumeUp, 5, time.Hour)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
 func TestDevice_Launch(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "/launch", r.URL.Path)
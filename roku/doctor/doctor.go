@@ -0,0 +1,377 @@
+package doctor
+
+/*
+Roku Docs
+Device discovery with SSDP
+https://developer.roku.com/docs/developer-program/debugging/external-control-api.md#ssdp-simple-service-discovery-protocol
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+	Skip Status = "skip"
+)
+
+// Check is one line of the doctor report
+type Check struct {
+	Name   string
+	Status Status
+	Detail string
+	// Fix suggests how to resolve a warning or failure
+	Fix string
+}
+
+// SSDPAddress is the multicast group and port SSDP searches are sent to
+const SSDPAddress = "239.255.255.250:1900"
+
+// MinFirmware is the oldest major Roku OS version checked without a warning
+const MinFirmware = 11
+
+// MaxClockSkew is how far the device clock may drift from this computer's
+// before it is reported
+const MaxClockSkew = 2 * time.Minute
+
+// Interface is a local network interface address
+type Interface struct {
+	Name      string
+	Net       *net.IPNet
+	Multicast bool
+}
+
+// LocalInterfaces lists the IPv4 addresses of the interfaces that are up,
+// leaving out loopback
+func LocalInterfaces() ([]Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var found []Interface
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || ipnet.IP.To4() == nil {
+				continue
+			}
+			found = append(found, Interface{Name: iface.Name, Net: ipnet, Multicast: iface.Flags&net.FlagMulticast != 0})
+		}
+	}
+	return found, nil
+}
+
+// Interfaces checks there is a multicast interface for discovery and that
+// the device is on one of its subnets. target may be nil when no device is
+// configured.
+func Interfaces(ifaces []Interface, target net.IP) Check {
+	check := Check{Name: "Interfaces"}
+	var names []string
+	multicast, local := false, false
+	for _, iface := range ifaces {
+		name := iface.Name + " " + iface.Net.String()
+		if iface.Multicast {
+			multicast = true
+		} else {
+			name += " (no multicast)"
+		}
+		names = append(names, name)
+		if target != nil && iface.Net.Contains(target) {
+			local = true
+		}
+	}
+	check.Detail = strings.Join(names, ", ")
+	switch {
+	case len(ifaces) == 0:
+		check.Status = Fail
+		check.Detail = "no interface with an IPv4 address is up"
+		check.Fix = "Connect this computer to the same network as the Roku"
+	case !multicast:
+		check.Status = Fail
+		check.Fix = "Discovery needs a multicast interface. Disconnect VPNs or tunnels and use Wi-Fi or Ethernet"
+	case target != nil && !local:
+		check.Status = Warn
+		check.Detail += fmt.Sprintf("; %s is not on a local subnet", target)
+		check.Fix = "The Roku is reached through a router, so discovery won't find it. Check this computer isn't on a guest network or VPN"
+	default:
+		check.Status = Pass
+	}
+	return check
+}
+
+// MulticastRoute checks the system can route packets to the SSDP group
+func MulticastRoute() Check {
+	check := Check{Name: "Multicast route"}
+	addr, err := net.ResolveUDPAddr("udp4", SSDPAddress)
+	if err != nil {
+		check.Status, check.Detail = Fail, err.Error()
+		return check
+	}
+	conn, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		check.Status, check.Detail = Fail, err.Error()
+		check.Fix = "Add a route for 239.0.0.0/8 or disconnect VPNs that capture all traffic"
+		return check
+	}
+	defer conn.Close()
+	check.Status = Pass
+	check.Detail = fmt.Sprintf("%s via %s", SSDPAddress, conn.LocalAddr().(*net.UDPAddr).IP)
+	return check
+}
+
+// Response is a raw answer to an SSDP search
+type Response struct {
+	// From is the IP that answered
+	From string
+	Raw  string
+	// Roku is set when the response advertises ECP
+	Roku bool
+}
+
+// Search sends an SSDP M-SEARCH for Rokus and collects every response
+// received within wait, one per address
+func Search(ctx context.Context, wait time.Duration) ([]Response, error) {
+	addr, err := net.ResolveUDPAddr("udp4", SSDPAddress)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a UDP socket: %w", err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	mx := max(int(wait/time.Second), 1)
+	msg := fmt.Sprintf("M-SEARCH * HTTP/1.1\r\nHOST: %s\r\nMAN: \"ssdp:discover\"\r\nST: %s\r\nMX: %d\r\n\r\n",
+		SSDPAddress, roku.RokuIdentifier, mx)
+	// Send twice, since either datagram can be lost
+	for range 2 {
+		if _, err := conn.WriteToUDP([]byte(msg), addr); err != nil {
+			return nil, fmt.Errorf("failed to send M-SEARCH: %w", err)
+		}
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(wait)); err != nil {
+		return nil, err
+	}
+
+	var responses []Response
+	seen := make(map[string]bool)
+	buf := make([]byte, 4096)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return responses, ctx.Err()
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return responses, nil
+			}
+			return responses, err
+		}
+		ip := from.IP.String()
+		if seen[ip] {
+			continue
+		}
+		seen[ip] = true
+		raw := string(buf[:n])
+		responses = append(responses, Response{
+			From: ip,
+			Raw:  raw,
+			Roku: strings.Contains(strings.ToLower(raw), roku.RokuIdentifier),
+		})
+	}
+}
+
+// SSDP checks the search found Rokus, including target when it is set
+func SSDP(responses []Response, err error, target net.IP, wait time.Duration) Check {
+	check := Check{Name: "SSDP discovery"}
+	if err != nil {
+		check.Status, check.Detail = Fail, err.Error()
+		return check
+	}
+	rokus, found := 0, false
+	for _, r := range responses {
+		if !r.Roku {
+			continue
+		}
+		rokus++
+		if target != nil && r.From == target.String() {
+			found = true
+		}
+	}
+	switch {
+	case rokus == 0:
+		check.Status = Fail
+		check.Detail = fmt.Sprintf("no Roku answered within %s (%d other responses)", wait, len(responses))
+		check.Fix = "Check the Roku is on and on this network, and that the router doesn't isolate Wi-Fi clients or filter multicast"
+	case target != nil && !found:
+		check.Status = Warn
+		check.Detail = fmt.Sprintf("%d Roku(s) answered, but not %s", rokus, target)
+		check.Fix = "The device's IP may have changed. Run 'roku find' to select it again"
+	default:
+		check.Status = Pass
+		check.Detail = fmt.Sprintf("%d Roku(s) answered", rokus)
+		if target != nil {
+			check.Detail += ", including " + target.String()
+		}
+	}
+	return check
+}
+
+// TCP dials the ECP port and reports the latency
+func TCP(ctx context.Context, address string) Check {
+	check := Check{Name: "TCP"}
+	dialer := net.Dialer{Timeout: 3 * time.Second}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		check.Status, check.Detail = Fail, err.Error()
+		check.Fix = "Check the Roku is powered on and its IP is current. A Roku with Control by mobile apps set to Disabled doesn't listen on port 8060"
+		return check
+	}
+	conn.Close()
+	check.Status = Pass
+	check.Detail = fmt.Sprintf("connected to %s in %s", address, latency(time.Since(start)))
+	return check
+}
+
+// HTTP requests the ECP root and reports the status and latency, returning
+// the device's Date header for Clock when it sent one
+func HTTP(ctx context.Context, client *http.Client, url string) (Check, time.Time) {
+	check := Check{Name: "HTTP"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		check.Status, check.Detail = Fail, err.Error()
+		return check, time.Time{}
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		check.Status, check.Detail = Fail, err.Error()
+		check.Fix = "The port is open but ECP didn't answer. Restart the Roku from Settings > System > Power"
+		return check, time.Time{}
+	}
+	resp.Body.Close()
+	check.Detail = fmt.Sprintf("GET %s %s in %s", url, resp.Status, latency(time.Since(start)))
+	date, _ := http.ParseTime(resp.Header.Get("Date"))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		check.Status = Fail
+		check.Fix = "ECP refused the request. Check Control by mobile apps in the Roku's settings"
+		return check, date
+	}
+	check.Status = Pass
+	return check, date
+}
+
+// Permission reports the device's Control by mobile apps mode
+func Permission(mode api.PermissionMode, err error) Check {
+	check := Check{Name: "Permission mode"}
+	if err != nil {
+		check.Status, check.Detail = Fail, err.Error()
+		return check
+	}
+	check.Detail = mode.Title()
+	const fix = "Open Settings > System > Advanced system settings > Control by mobile apps on the Roku and choose Enabled"
+	switch mode {
+	case api.PermissionEnabled:
+		check.Status = Pass
+	case api.PermissionPermissive:
+		check.Status = Warn
+		check.Fix = "Permissive accepts commands from outside your network. Enabled is enough for this CLI"
+	case api.PermissionLimited:
+		check.Status = Warn
+		check.Fix = fix
+	default:
+		check.Status = Fail
+		check.Fix = fix
+	}
+	return check
+}
+
+// Firmware reports the Roku OS version, warning when it is older than
+// MinFirmware
+func Firmware(info *api.DeviceInfo) Check {
+	check := Check{Name: "Firmware", Status: Pass}
+	check.Detail = strings.TrimSpace(fmt.Sprintf("Roku OS %s %s", info.SoftwareVersion, info.SoftwareBuild))
+	major, err := strconv.Atoi(strings.SplitN(info.SoftwareVersion, ".", 2)[0])
+	switch {
+	case err != nil:
+		check.Status = Warn
+		check.Detail = fmt.Sprintf("unknown version %q", info.SoftwareVersion)
+	case major < MinFirmware:
+		check.Status = Warn
+		check.Fix = fmt.Sprintf("Some ECP queries need Roku OS %d or later. Update from Settings > System > System update", MinFirmware)
+	}
+	return check
+}
+
+// Clock compares the device's clock and time zone with this computer's.
+// Schedules run on this computer's clock, so a device that disagrees
+// appears to act at the wrong time.
+func Clock(now, device time.Time, info *api.DeviceInfo) Check {
+	check := Check{Name: "Clock", Status: Pass}
+	var problems []string
+	if device.IsZero() {
+		check.Detail = "device sent no Date header"
+	} else {
+		// The Date header has whole seconds, so a second of skew is expected
+		skew := device.Sub(now).Round(time.Second)
+		check.Detail = fmt.Sprintf("device clock %s", describeSkew(skew))
+		if skew > MaxClockSkew || skew < -MaxClockSkew {
+			problems = append(problems, check.Detail)
+		}
+	}
+	if info != nil && info.TimeZoneName != "" {
+		_, offset := now.Zone()
+		if info.TimeZoneOffset != offset/60 {
+			problems = append(problems, fmt.Sprintf("device time zone %s is UTC%+.1f, this computer is UTC%+.1f",
+				info.TimeZoneName, float64(info.TimeZoneOffset)/60, float64(offset)/3600))
+		}
+	}
+	if len(problems) > 0 {
+		check.Status = Warn
+		check.Detail = strings.Join(problems, "; ")
+		check.Fix = "Scheduled actions use this computer's clock and time zone. Set both to network time, and the Roku's time zone in Settings > System > Time"
+	}
+	return check
+}
+
+// latency rounds a duration to a readable precision for LAN round trips
+func latency(d time.Duration) time.Duration {
+	return d.Round(100 * time.Microsecond)
+}
+
+func describeSkew(skew time.Duration) string {
+	switch {
+	case skew > time.Second:
+		return fmt.Sprintf("is %s ahead", skew)
+	case skew < -time.Second:
+		return fmt.Sprintf("is %s behind", -skew)
+	}
+	return "matches"
+}
@@ -0,0 +1,129 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustCIDR(t *testing.T, cidr string) *net.IPNet {
+	t.Helper()
+	ip, ipnet, err := net.ParseCIDR(cidr)
+	require.NoError(t, err)
+	ipnet.IP = ip
+	return ipnet
+}
+
+func TestInterfaces(t *testing.T) {
+	wifi := Interface{Name: "en0", Net: mustCIDR(t, "192.168.1.10/24"), Multicast: true}
+	tunnel := Interface{Name: "utun0", Net: mustCIDR(t, "10.8.0.2/32")}
+
+	check := Interfaces([]Interface{wifi, tunnel}, net.ParseIP("192.168.1.20"))
+	assert.Equal(t, Pass, check.Status)
+	assert.Equal(t, "en0 192.168.1.10/24, utun0 10.8.0.2/32 (no multicast)", check.Detail)
+
+	check = Interfaces([]Interface{wifi}, net.ParseIP("192.168.2.20"))
+	assert.Equal(t, Warn, check.Status)
+	assert.Contains(t, check.Detail, "192.168.2.20 is not on a local subnet")
+
+	assert.Equal(t, Fail, Interfaces([]Interface{tunnel}, nil).Status)
+	assert.Equal(t, Fail, Interfaces(nil, nil).Status)
+}
+
+func TestSSDP(t *testing.T) {
+	responses := []Response{
+		{From: "192.168.1.1", Raw: "HTTP/1.1 200 OK\r\nST: upnp:rootdevice\r\n"},
+		{From: "192.168.1.20", Raw: "HTTP/1.1 200 OK\r\nST: roku:ecp\r\nLOCATION: http://192.168.1.20:8060/\r\n", Roku: true},
+	}
+
+	check := SSDP(responses, nil, net.ParseIP("192.168.1.20"), time.Second)
+	assert.Equal(t, Pass, check.Status)
+	assert.Equal(t, "1 Roku(s) answered, including 192.168.1.20", check.Detail)
+
+	check = SSDP(responses, nil, net.ParseIP("192.168.1.21"), time.Second)
+	assert.Equal(t, Warn, check.Status)
+	assert.Contains(t, check.Fix, "roku find")
+
+	check = SSDP(responses[:1], nil, nil, time.Second)
+	assert.Equal(t, Fail, check.Status)
+	assert.Equal(t, "no Roku answered within 1s (1 other responses)", check.Detail)
+
+	assert.Equal(t, Fail, SSDP(nil, errors.New("no socket"), nil, time.Second).Status)
+}
+
+func TestTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+
+	check := TCP(context.Background(), address)
+	assert.Equal(t, Pass, check.Status)
+	assert.Contains(t, check.Detail, "connected to "+address)
+
+	listener.Close()
+	assert.Equal(t, Fail, TCP(context.Background(), address).Status)
+}
+
+func TestHTTP(t *testing.T) {
+	date := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", date.Format(http.TimeFormat))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	check, got := HTTP(context.Background(), server.Client(), server.URL)
+	assert.Equal(t, Pass, check.Status)
+	assert.Contains(t, check.Detail, "200 OK")
+	assert.True(t, date.Equal(got))
+
+	status = http.StatusForbidden
+	check, _ = HTTP(context.Background(), server.Client(), server.URL)
+	assert.Equal(t, Fail, check.Status)
+}
+
+func TestPermission(t *testing.T) {
+	assert.Equal(t, Pass, Permission(api.PermissionEnabled, nil).Status)
+	check := Permission(api.PermissionLimited, nil)
+	assert.Equal(t, Warn, check.Status)
+	assert.Equal(t, "Limited", check.Detail)
+	assert.Contains(t, check.Fix, "Control by mobile apps")
+	assert.Equal(t, Warn, Permission(api.PermissionPermissive, nil).Status)
+	assert.Equal(t, Fail, Permission(api.PermissionDisabled, nil).Status)
+	assert.Equal(t, Fail, Permission(api.PermissionUnknown, errors.New("timeout")).Status)
+}
+
+func TestFirmware(t *testing.T) {
+	check := Firmware(&api.DeviceInfo{SoftwareVersion: "12.5.0", SoftwareBuild: "4178"})
+	assert.Equal(t, Pass, check.Status)
+	assert.Equal(t, "Roku OS 12.5.0 4178", check.Detail)
+	assert.Equal(t, Warn, Firmware(&api.DeviceInfo{SoftwareVersion: "9.4.0"}).Status)
+	assert.Equal(t, Warn, Firmware(&api.DeviceInfo{}).Status)
+}
+
+func TestClock(t *testing.T) {
+	zone := time.FixedZone("EST", -5*3600)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, zone)
+	info := &api.DeviceInfo{TimeZoneName: "US/Eastern", TimeZoneOffset: -300}
+
+	check := Clock(now, now.Add(time.Second), info)
+	assert.Equal(t, Pass, check.Status)
+	assert.Equal(t, "device clock matches", check.Detail)
+
+	check = Clock(now, now.Add(-5*time.Minute), info)
+	assert.Equal(t, Warn, check.Status)
+	assert.Equal(t, "device clock is 5m0s behind", check.Detail)
+
+	check = Clock(now, time.Time{}, &api.DeviceInfo{TimeZoneName: "US/Pacific", TimeZoneOffset: -480})
+	assert.Equal(t, Warn, check.Status)
+	assert.Equal(t, "device time zone US/Pacific is UTC-8.0, this computer is UTC-5.0", check.Detail)
+}
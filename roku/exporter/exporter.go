@@ -0,0 +1,161 @@
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// namespace prefixes every metric name
+const namespace = "roku"
+
+// DefaultScrapeTimeout bounds the ECP requests made for a single scrape
+const DefaultScrapeTimeout = 5 * time.Second
+
+var (
+	upDesc = prometheus.NewDesc(namespace+"_up",
+		"Whether the device answered the last scrape.", []string{"device"}, nil)
+	infoDesc = prometheus.NewDesc(namespace+"_device_info",
+		"Device metadata, always 1.", []string{"device", "name", "model", "software_version", "serial_number"}, nil)
+	uptimeDesc = prometheus.NewDesc(namespace+"_uptime_seconds",
+		"Time since the device booted.", []string{"device"}, nil)
+	powerModeDesc = prometheus.NewDesc(namespace+"_power_mode",
+		"Current power mode, 1 for the active mode.", []string{"device", "mode"}, nil)
+	activeAppDesc = prometheus.NewDesc(namespace+"_active_app",
+		"Currently active app, 1 for the active app.", []string{"device", "app_id", "app_name"}, nil)
+	playerStateDesc = prometheus.NewDesc(namespace+"_player_state",
+		"Current media player state, 1 for the active state.", []string{"device", "state"}, nil)
+)
+
+// Exporter is a prometheus.Collector that queries each device over ECP when
+// scraped. It also records the latency and errors of every ECP request made.
+type Exporter struct {
+	devices []*roku.Device
+	// Timeout bounds the requests made to each device per scrape
+	Timeout time.Duration
+
+	requestDuration *prometheus.HistogramVec
+	requestErrors   *prometheus.CounterVec
+}
+
+// New creates an exporter for the devices at ips. ECP requests are sent with
+// base, or http.DefaultTransport when nil, and traced to logger when set.
+func New(ips []string, base http.RoundTripper, logger *slog.Logger) *Exporter {
+	e := &Exporter{
+		Timeout: DefaultScrapeTimeout,
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "ecp_request_duration_seconds",
+			Help:      "Latency of ECP requests.",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"device", "endpoint"}),
+		requestErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ecp_request_errors_total",
+			Help:      "ECP requests that failed or returned a non 2xx status.",
+		}, []string{"device", "endpoint"}),
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport := &instrumentedTransport{base: base, exporter: e}
+	for _, ip := range ips {
+		e.devices = append(e.devices, roku.NewDevice(ip, roku.WithTransport(transport), roku.WithLogger(logger)))
+	}
+	return e
+}
+
+// Describe implements prometheus.Collector
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- upDesc
+	ch <- infoDesc
+	ch <- uptimeDesc
+	ch <- powerModeDesc
+	ch <- activeAppDesc
+	ch <- playerStateDesc
+	e.requestDuration.Describe(ch)
+	e.requestErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector, scraping all devices concurrently
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	var wg sync.WaitGroup
+	for _, device := range e.devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), e.Timeout)
+			defer cancel()
+			e.collectDevice(ctx, device, ch)
+		}()
+	}
+	wg.Wait()
+	e.requestDuration.Collect(ch)
+	e.requestErrors.Collect(ch)
+}
+
+// collectDevice emits the state of one device. The app and player are only
+// queried while the device is on since they are meaningless in standby.
+func (e *Exporter) collectDevice(ctx context.Context, device *roku.Device, ch chan<- prometheus.Metric) {
+	info, err := device.DeviceInfo(ctx)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0, device.IP)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1, device.IP)
+	ch <- prometheus.MustNewConstMetric(infoDesc, prometheus.GaugeValue, 1, device.IP,
+		info.FriendlyDeviceName, info.ModelName, info.SoftwareVersion, info.SerialNumber)
+	ch <- prometheus.MustNewConstMetric(uptimeDesc, prometheus.GaugeValue, float64(info.Uptime), device.IP)
+	if info.PowerMode != "" {
+		ch <- prometheus.MustNewConstMetric(powerModeDesc, prometheus.GaugeValue, 1, device.IP, info.PowerMode)
+	}
+	if info.PowerMode != "" && info.PowerMode != "PowerOn" {
+		return
+	}
+
+	if active, err := device.ActiveApp(ctx); err == nil {
+		ch <- prometheus.MustNewConstMetric(activeAppDesc, prometheus.GaugeValue, 1, device.IP, active.App.ID, active.App.Name)
+	}
+	if player, err := device.Player(ctx); err == nil {
+		ch <- prometheus.MustNewConstMetric(playerStateDesc, prometheus.GaugeValue, 1, device.IP, player.State)
+	}
+}
+
+// instrumentedTransport records request latency and errors for the exporter
+type instrumentedTransport struct {
+	base     http.RoundTripper
+	exporter *Exporter
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	device, endpoint := req.URL.Hostname(), endpointLabel(req.URL.Path)
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	t.exporter.requestDuration.WithLabelValues(device, endpoint).Observe(time.Since(start).Seconds())
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		t.exporter.requestErrors.WithLabelValues(device, endpoint).Inc()
+	}
+	return resp, err
+}
+
+// endpointLabel trims per-app suffixes such as /query/icon/12 so that label
+// cardinality stays bounded
+func endpointLabel(path string) string {
+	for _, prefix := range []string{api.EndpointKeypress, api.EndpointKeydown, api.EndpointLaunch, api.EndpointInstall} {
+		if strings.HasPrefix(path, prefix+"/") {
+			return prefix
+		}
+	}
+	if strings.HasPrefix(path, "/query/") {
+		parts := strings.SplitN(strings.TrimPrefix(path, "/query/"), "/", 2)
+		return "/query/" + parts[0]
+	}
+	return path
+}
@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redirectTransport sends requests for reachable devices to the test server
+// and fails the rest
+type redirectTransport struct {
+	serverURL string
+	reachable string
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Hostname() != t.reachable {
+		return nil, fmt.Errorf("dial %s: connection refused", req.URL.Host)
+	}
+	req = req.Clone(req.Context())
+	req.URL.Host = strings.TrimPrefix(t.serverURL, "http://")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestExporter_Collect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case api.EndpointDeviceInfo:
+			fmt.Fprint(w, `<device-info>
+	<serial-number>YN00H5123456</serial-number>
+	<model-name>Roku Ultra</model-name>
+	<friendly-device-name>Living Room</friendly-device-name>
+	<software-version>12.5.0</software-version>
+	<uptime>3600</uptime>
+	<power-mode>PowerOn</power-mode>
+</device-info>`)
+		case api.EndpointActiveApp:
+			fmt.Fprint(w, `<active-app><app id="12">Netflix</app></active-app>`)
+		case api.EndpointMediaPlayer:
+			fmt.Fprint(w, `<player error="false" state="play"></player>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	exporter := New([]string{"192.168.1.10", "192.168.1.11"}, &redirectTransport{serverURL: server.URL, reachable: "192.168.1.10"}, nil)
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(exporter))
+
+	expected := `
+# HELP roku_active_app Currently active app, 1 for the active app.
+# TYPE roku_active_app gauge
+roku_active_app{app_id="12",app_name="Netflix",device="192.168.1.10"} 1
+# HELP roku_device_info Device metadata, always 1.
+# TYPE roku_device_info gauge
+roku_device_info{device="192.168.1.10",model="Roku Ultra",name="Living Room",serial_number="YN00H5123456",software_version="12.5.0"} 1
+# HELP roku_player_state Current media player state, 1 for the active state.
+# TYPE roku_player_state gauge
+roku_player_state{device="192.168.1.10",state="play"} 1
+# HELP roku_power_mode Current power mode, 1 for the active mode.
+# TYPE roku_power_mode gauge
+roku_power_mode{device="192.168.1.10",mode="PowerOn"} 1
+# HELP roku_up Whether the device answered the last scrape.
+# TYPE roku_up gauge
+roku_up{device="192.168.1.10"} 1
+roku_up{device="192.168.1.11"} 0
+# HELP roku_uptime_seconds Time since the device booted.
+# TYPE roku_uptime_seconds gauge
+roku_uptime_seconds{device="192.168.1.10"} 3600
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"roku_active_app", "roku_device_info", "roku_player_state", "roku_power_mode", "roku_up", "roku_uptime_seconds")
+	assert.NoError(t, err)
+
+	// The unreachable device is retried by the client, each failure counted
+	assert.Equal(t, float64(api.MaxRetries), testutil.ToFloat64(exporter.requestErrors.WithLabelValues("192.168.1.11", api.EndpointDeviceInfo)))
+	assert.Equal(t, 1, testutil.CollectAndCount(exporter.requestErrors), "the reachable device should have no errors")
+	assert.Equal(t, 4, testutil.CollectAndCount(exporter.requestDuration), "one series per device and endpoint")
+}
+
+func TestEndpointLabel(t *testing.T) {
+	tests := map[string]string{
+		"/query/device-info": "/query/device-info",
+		"/query/icon/12":     "/query/icon",
+		"/keypress/Home":     "/keypress",
+		"/launch/12":         "/launch",
+		"/":                  "/",
+	}
+	for path, want := range tests {
+		assert.Equal(t, want, endpointLabel(path), path)
+	}
+}
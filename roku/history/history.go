@@ -0,0 +1,128 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Content describes what was playing during a session, as far as the media
+// player reports it. ECP does not expose titles.
+type Content struct {
+	PluginID        string `json:"plugin_id,omitempty"`
+	PluginName      string `json:"plugin_name,omitempty"`
+	DurationSeconds int64  `json:"duration_seconds,omitempty"`
+	Live            bool   `json:"live,omitempty"`
+	Video           string `json:"video,omitempty"`
+	Resolution      string `json:"resolution,omitempty"`
+}
+
+// Session is one continuous stretch of an app being open on a device
+type Session struct {
+	Device string    `json:"device"`
+	AppID  string    `json:"app_id"`
+	App    string    `json:"app"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	// PlayedSeconds is how much of the session the media player was playing
+	PlayedSeconds int64    `json:"played_seconds"`
+	Content       *Content `json:"content,omitempty"`
+}
+
+// Duration is how long the app was open
+func (s Session) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Filter selects sessions from a Log. Zero fields match everything.
+type Filter struct {
+	Device string
+	// App matches the app id or name, case-insensitively
+	App string
+	// From and To bound the session start time
+	From time.Time
+	To   time.Time
+}
+
+// Match reports whether the session passes the filter
+func (f Filter) Match(s Session) bool {
+	if f.Device != "" && f.Device != s.Device {
+		return false
+	}
+	if f.App != "" && !strings.EqualFold(f.App, s.AppID) && !strings.EqualFold(f.App, s.App) {
+		return false
+	}
+	if !f.From.IsZero() && s.Start.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && s.Start.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// Log is an append-only file of sessions, one JSON object per line
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLog returns the log stored at path
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
+
+// Append adds a session to the end of the log
+func (l *Log) Append(s Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Query returns the sessions matching filter in the order they were recorded.
+// Lines that can't be parsed, such as one cut short by a crash, are skipped.
+func (l *Log) Query(filter Filter) ([]Session, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var sessions []Session
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s Session
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			continue
+		}
+		if filter.Match(s) {
+			sessions = append(sessions, s)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return sessions, nil
+}
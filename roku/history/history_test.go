@@ -0,0 +1,198 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// customTransport redirects device requests to the test server
+type customTransport struct {
+	serverURL string
+}
+
+func (t *customTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Host = strings.TrimPrefix(t.serverURL, "http://")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// fakeDevice serves device-info, active-app and media-player from settable
+// state
+type fakeDevice struct {
+	mu     sync.Mutex
+	power  string
+	app    string
+	player string
+}
+
+func (f *fakeDevice) set(power, app, player string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.power, f.app, f.player = power, app, player
+}
+
+func (f *fakeDevice) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case api.EndpointDeviceInfo:
+		fmt.Fprintf(w, `<device-info><power-mode>%s</power-mode></device-info>`, f.power)
+	case api.EndpointActiveApp:
+		fmt.Fprintf(w, `<active-app>%s</active-app>`, f.app)
+	case api.EndpointMediaPlayer:
+		fmt.Fprint(w, f.player)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+const (
+	youtube = `<app id="837">YouTube</app>`
+	netflix = `<app id="12">Netflix</app>`
+	home    = `<app>Roku</app>`
+	playing = `<player error="false" state="play"><plugin id="12" bandwidth="1000" name="Netflix"/>` +
+		`<format audio="aac" video="h264" vidRes="1920x1080"/><duration>3600000 ms</duration><is_live>false</is_live></player>`
+	idle = `<player error="false" state="close"/>`
+)
+
+// testRecorder feeds a Recorder samples of its devices, as roku.Sampler does
+type testRecorder struct {
+	*Recorder
+	devices []*roku.Device
+}
+
+func (tr *testRecorder) Tick(ctx context.Context, now time.Time) {
+	_ = tr.Observe(ctx, roku.SampleAll(ctx, tr.devices), now)
+}
+
+func newTestRecorder(t *testing.T) (*testRecorder, *fakeDevice) {
+	t.Helper()
+	fake := &fakeDevice{power: "PowerOn", app: home, player: idle}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	device := &roku.Device{
+		IP:     "192.168.1.21",
+		Client: api.NewClient("192.168.1.21", &http.Client{Transport: &customTransport{serverURL: server.URL}}),
+	}
+	recorder := &Recorder{
+		Log:      NewLog(filepath.Join(t.TempDir(), "history.jsonl")),
+		Interval: 30 * time.Second,
+	}
+	return &testRecorder{Recorder: recorder, devices: []*roku.Device{device}}, fake
+}
+
+func TestRecorder_Sessions(t *testing.T) {
+	r, fake := newTestRecorder(t)
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	// The home screen is not a session
+	r.Tick(ctx, at(0))
+	fake.set("PowerOn", youtube, idle)
+	r.Tick(ctx, at(30))
+	r.Tick(ctx, at(60))
+	// Switching app ends the YouTube session
+	fake.set("PowerOn", netflix, playing)
+	r.Tick(ctx, at(90))
+	r.Tick(ctx, at(120))
+	r.Tick(ctx, at(150))
+	// Powering off ends the Netflix session
+	fake.set("DisplayOff", netflix, playing)
+	r.Tick(ctx, at(180))
+
+	sessions, err := r.Log.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	assert.Equal(t, "837", sessions[0].AppID)
+	assert.Equal(t, "YouTube", sessions[0].App)
+	assert.Equal(t, at(30), sessions[0].Start)
+	assert.Equal(t, at(90), sessions[0].End)
+	assert.Zero(t, sessions[0].PlayedSeconds)
+	assert.Nil(t, sessions[0].Content)
+
+	assert.Equal(t, "Netflix", sessions[1].App)
+	assert.Equal(t, 90*time.Second, sessions[1].Duration())
+	assert.Equal(t, int64(60), sessions[1].PlayedSeconds)
+	require.NotNil(t, sessions[1].Content)
+	assert.Equal(t, Content{
+		PluginID:        "12",
+		PluginName:      "Netflix",
+		DurationSeconds: 3600,
+		Video:           "h264",
+		Resolution:      "1920x1080",
+	}, *sessions[1].Content)
+}
+
+func TestRecorder_GapAndClose(t *testing.T) {
+	r, fake := newTestRecorder(t)
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	fake.set("PowerOn", youtube, idle)
+	r.Tick(ctx, at(0))
+	r.Tick(ctx, at(30))
+	// An hour without samples ends the session when it was last seen
+	r.Tick(ctx, at(3630))
+	r.Close()
+
+	sessions, err := r.Log.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, at(30), sessions[0].End)
+	assert.Equal(t, at(3630), sessions[1].Start)
+	assert.Equal(t, at(3630), sessions[1].End)
+}
+
+func TestLog_Query(t *testing.T) {
+	log := NewLog(filepath.Join(t.TempDir(), "history.jsonl"))
+	day := time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)
+	for _, s := range []Session{
+		{Device: "192.168.1.21", AppID: "837", App: "YouTube", Start: day, End: day.Add(time.Hour)},
+		{Device: "192.168.1.22", AppID: "12", App: "Netflix", Start: day.AddDate(0, 0, 1), End: day.AddDate(0, 0, 1).Add(time.Hour)},
+		{Device: "192.168.1.21", AppID: "12", App: "Netflix", Start: day.AddDate(0, 0, 2), End: day.AddDate(0, 0, 2).Add(time.Hour)},
+	} {
+		require.NoError(t, log.Append(s))
+	}
+	// A line cut short by a crash is skipped
+	f, err := os.OpenFile(log.path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"device":"192.168.1.21","app_id":"8`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	apps := func(filter Filter) []string {
+		sessions, err := log.Query(filter)
+		require.NoError(t, err)
+		var ids []string
+		for _, s := range sessions {
+			ids = append(ids, s.Device+"/"+s.AppID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"192.168.1.21/837", "192.168.1.22/12", "192.168.1.21/12"}, apps(Filter{}))
+	assert.Equal(t, []string{"192.168.1.21/837", "192.168.1.21/12"}, apps(Filter{Device: "192.168.1.21"}))
+	assert.Equal(t, []string{"192.168.1.22/12", "192.168.1.21/12"}, apps(Filter{App: "netflix"}))
+	assert.Equal(t, []string{"192.168.1.22/12"}, apps(Filter{From: day.Add(time.Hour), To: day.AddDate(0, 0, 1).Add(time.Hour)}))
+
+	empty := NewLog(filepath.Join(t.TempDir(), "missing.jsonl"))
+	sessions, err := empty.Query(Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
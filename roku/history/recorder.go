@@ -0,0 +1,148 @@
+package history
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+)
+
+// DefaultInterval is how often devices are sampled
+const DefaultInterval = 30 * time.Second
+
+// Recorder appends a Session to a Log whenever an app is closed, the device
+// turns off or it stops responding. It is fed by a roku.Sampler through
+// Observe. The home screen is not recorded.
+type Recorder struct {
+	Log *Log
+	// Interval between samples, DefaultInterval when zero
+	Interval time.Duration
+	// Logger records write failures, discarded when nil
+	Logger *slog.Logger
+
+	mu   sync.Mutex
+	open map[string]*openSession
+}
+
+// openSession is a session still in progress
+type openSession struct {
+	Session
+	lastSeen    time.Time
+	lastPlaying bool
+}
+
+// Observe updates the open session of each device from a round of samples
+func (r *Recorder) Observe(ctx context.Context, samples []roku.DeviceSample, now time.Time) error {
+	for _, ds := range samples {
+		r.observeDevice(ds, now)
+	}
+	return nil
+}
+
+// Close records every open session as ending when it was last seen
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	open := r.open
+	r.open = nil
+	r.mu.Unlock()
+	for _, s := range open {
+		r.finish(s, s.lastSeen)
+	}
+}
+
+func (r *Recorder) observeDevice(ds roku.DeviceSample, now time.Time) {
+	device, err := ds.Device, ds.Err
+	// A nil app means the device is off or on the home screen
+	var app *api.App
+	if ds.Sample.On && ds.Sample.App.ID != "" {
+		app = &ds.Sample.App
+	}
+	player := ds.Sample.Player
+
+	r.mu.Lock()
+	if r.open == nil {
+		r.open = make(map[string]*openSession)
+	}
+	current := r.open[device.IP]
+	var ended *openSession
+	endAt := now
+	switch {
+	case current == nil:
+	case now.Sub(current.lastSeen) > 2*r.interval():
+		// Samples were missed, so end the session when it was last seen
+		ended, endAt = current, current.lastSeen
+	case err != nil || app == nil || app.ID != current.AppID:
+		ended = current
+	}
+	if ended != nil {
+		delete(r.open, device.IP)
+		current = nil
+	}
+	if err == nil && app != nil {
+		if current == nil {
+			current = &openSession{Session: Session{Device: device.IP, AppID: app.ID, App: app.Name, Start: now}}
+			r.open[device.IP] = current
+		} else if current.lastPlaying {
+			current.PlayedSeconds += int64(now.Sub(current.lastSeen).Seconds())
+		}
+		current.lastSeen = now
+		current.End = now
+		current.lastPlaying = player != nil && player.State == "play"
+		if player != nil {
+			updateContent(&current.Session, player)
+		}
+	}
+	r.mu.Unlock()
+
+	if ended != nil {
+		r.finish(ended, endAt)
+	}
+}
+
+// updateContent fills in content details reported by the media player
+func updateContent(s *Session, player *api.Player) {
+	if player.Plugin.ID == "" && player.Duration == 0 && player.Format.Video == "" {
+		return
+	}
+	if s.Content == nil {
+		s.Content = &Content{}
+	}
+	c := s.Content
+	if player.Plugin.ID != "" {
+		c.PluginID, c.PluginName = player.Plugin.ID, player.Plugin.Name
+	}
+	if player.Duration > 0 {
+		c.DurationSeconds = int64(player.Duration.Seconds())
+	}
+	c.Live = c.Live || player.Live
+	if player.Format.Video != "" {
+		c.Video = player.Format.Video
+	}
+	if player.Format.VideoRes != "" {
+		c.Resolution = player.Format.VideoRes
+	}
+}
+
+func (r *Recorder) finish(s *openSession, end time.Time) {
+	s.End = end
+	if err := r.Log.Append(s.Session); err != nil {
+		r.logger().Warn("failed to record session", "device", s.Device, "app", s.App, "error", err)
+	}
+}
+
+func (r *Recorder) interval() time.Duration {
+	if r.Interval <= 0 {
+		return DefaultInterval
+	}
+	return r.Interval
+}
+
+func (r *Recorder) logger() *slog.Logger {
+	if r.Logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return r.Logger
+}
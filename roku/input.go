@@ -0,0 +1,80 @@
+package roku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// inputPrefix starts the app id of every TV input
+const inputPrefix = "tvinput."
+
+// ErrInputNotFound is returned by FindInput when no input matches
+var ErrInputNotFound = errors.New("input not found")
+
+// Input is a TV input source such as an HDMI port. Roku TVs list each input
+// in /query/apps as a tvin app named with the label set on the TV.
+type Input struct {
+	// ID is the app id, such as tvinput.hdmi2
+	ID string
+	// Port is the id without its prefix, such as hdmi2, dtv or cvbs
+	Port string
+	// Label is the name shown on the TV, such as PlayStation
+	Label string
+}
+
+// PortName describes the physical port, such as "HDMI 2" or "AV"
+func (i Input) PortName() string {
+	switch {
+	case strings.HasPrefix(i.Port, "hdmi"):
+		return strings.TrimSpace("HDMI " + strings.TrimPrefix(i.Port, "hdmi"))
+	case i.Port == "dtv":
+		return "Tuner"
+	case i.Port == "cvbs" || strings.HasPrefix(i.Port, "av"):
+		return "AV"
+	}
+	return i.Port
+}
+
+// Inputs lists the TV inputs of the device in the order the TV reports
+// them. Devices without inputs, such as streaming sticks, return none.
+func (d *Device) Inputs(ctx context.Context) ([]Input, error) {
+	apps, err := d.FetchInstalledApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var inputs []Input
+	for _, app := range apps.Apps {
+		if !strings.HasPrefix(app.ID, inputPrefix) {
+			continue
+		}
+		inputs = append(inputs, Input{
+			ID:    app.ID,
+			Port:  strings.TrimPrefix(app.ID, inputPrefix),
+			Label: app.Name,
+		})
+	}
+	return inputs, nil
+}
+
+// FindInput looks up an input by its label, id, port such as hdmi2, or port
+// name such as "HDMI 2", case-insensitively
+func (d *Device) FindInput(ctx context.Context, name string) (*Input, error) {
+	inputs, err := d.Inputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, input := range inputs {
+		if strings.EqualFold(input.Label, name) || strings.EqualFold(input.ID, name) ||
+			strings.EqualFold(input.Port, name) || strings.EqualFold(input.PortName(), name) {
+			return &input, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInputNotFound, name)
+}
+
+// SetInput switches the TV to an input
+func (d *Device) SetInput(ctx context.Context, input Input) error {
+	return d.Launch(ctx, input.ID)
+}
@@ -0,0 +1,60 @@
+package roku
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevice_Inputs(t *testing.T) {
+	var launched string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			launched = r.URL.Path + " " + string(body)
+			return
+		}
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<apps>
+	<app id="tvinput.hdmi1" type="tvin" version="1.0.0">Apple TV</app>
+	<app id="tvinput.hdmi2" type="tvin" version="1.0.0">PlayStation</app>
+	<app id="tvinput.hdmi3" type="tvin" version="1.0.0">HDMI 3</app>
+	<app id="tvinput.cvbs" type="tvin" version="1.0.0">AV</app>
+	<app id="tvinput.dtv" type="tvin" version="1.0.0">Live TV</app>
+	<app id="12" type="appl" version="5.2.1">Netflix</app>
+</apps>`))
+	}))
+	defer server.Close()
+
+	device := createTestDevice(server)
+	ctx := context.Background()
+
+	inputs, err := device.Inputs(ctx)
+	require.NoError(t, err)
+	require.Len(t, inputs, 5)
+	assert.Equal(t, Input{ID: "tvinput.hdmi2", Port: "hdmi2", Label: "PlayStation"}, inputs[1])
+	var ports []string
+	for _, input := range inputs {
+		ports = append(ports, input.PortName())
+	}
+	assert.Equal(t, []string{"HDMI 1", "HDMI 2", "HDMI 3", "AV", "Tuner"}, ports)
+
+	for _, name := range []string{"playstation", "hdmi2", "HDMI 2", "tvinput.hdmi2"} {
+		input, err := device.FindInput(ctx, name)
+		require.NoError(t, err, name)
+		assert.Equal(t, "tvinput.hdmi2", input.ID, name)
+	}
+
+	_, err = device.FindInput(ctx, "Xbox")
+	assert.ErrorIs(t, err, ErrInputNotFound)
+
+	input, err := device.FindInput(ctx, "live tv")
+	require.NoError(t, err)
+	require.NoError(t, device.SetInput(ctx, *input))
+	assert.Equal(t, "/launch id=tvinput.dtv", launched)
+}
@@ -0,0 +1,62 @@
+package roku
+
+import (
+	"context"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku/api"
+)
+
+// PermissionTTL is how long a probed permission mode is reused before the
+// device is asked again
+const PermissionTTL = time.Minute
+
+// Feature is a group of commands that needs a permission mode
+type Feature struct {
+	Name string
+	// Limited is set for features Limited mode still allows
+	Limited bool
+}
+
+// Features lists what the CLI does over ECP, in the order doctor reports it
+var Features = []Feature{
+	{Name: "Read device info, installed apps and the active app", Limited: true},
+	{Name: "Launch apps and switch inputs", Limited: true},
+	{Name: "Type text into on-screen keyboards", Limited: true},
+	{Name: "Send remote keys, including power and volume"},
+	{Name: "Read the media player for live, watch, history and metrics"},
+	{Name: "Search, and install apps from the channel store"},
+}
+
+// Allows reports whether a device in the permission mode accepts the
+// commands of a feature. Nothing is allowed when the mode is unknown.
+func Allows(mode api.PermissionMode, feature Feature) bool {
+	switch mode {
+	case api.PermissionEnabled, api.PermissionPermissive:
+		return true
+	case api.PermissionLimited:
+		return feature.Limited
+	}
+	return false
+}
+
+// Permission probes the device's "Control by mobile apps" mode. The result
+// is cached for devices created with NewDevice for PermissionTTL, so a
+// long-running daemon sees the setting change within a minute.
+func (d *Device) Permission(ctx context.Context) (api.PermissionMode, error) {
+	if d.cache == nil {
+		return d.Client.ProbePermission(ctx)
+	}
+	d.cache.mu.Lock()
+	defer d.cache.mu.Unlock()
+	if d.cache.permission != api.PermissionUnknown && time.Since(d.cache.permissionAt) < PermissionTTL {
+		return d.cache.permission, nil
+	}
+	mode, err := d.Client.ProbePermission(ctx)
+	if err != nil {
+		return api.PermissionUnknown, err
+	}
+	d.cache.permission = mode
+	d.cache.permissionAt = time.Now()
+	return mode, nil
+}
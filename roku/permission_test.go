@@ -0,0 +1,55 @@
+package roku
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllows(t *testing.T) {
+	keys := Features[3]
+	launch := Features[1]
+	assert.True(t, Allows(api.PermissionEnabled, keys))
+	assert.True(t, Allows(api.PermissionPermissive, keys))
+	assert.False(t, Allows(api.PermissionLimited, keys))
+	assert.True(t, Allows(api.PermissionLimited, launch))
+	assert.False(t, Allows(api.PermissionDisabled, launch))
+	assert.False(t, Allows(api.PermissionUnknown, launch))
+}
+
+func TestDevice_Permission(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == api.EndpointMediaPlayer {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("ECP command not allowed in Limited mode."))
+			return
+		}
+		_, _ = w.Write([]byte(`<device-info><model-name>Roku Ultra</model-name></device-info>`))
+	}))
+	defer server.Close()
+
+	device := createTestDevice(server)
+	device.cache = &deviceCache{}
+	ctx := context.Background()
+
+	for range 2 {
+		mode, err := device.Permission(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, api.PermissionLimited, mode)
+	}
+	assert.Equal(t, 2, requests)
+
+	// The mode is probed again once the cached one expires
+	device.cache.permissionAt = time.Now().Add(-PermissionTTL)
+	_, err := device.Permission(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, requests)
+}
@@ -2,8 +2,9 @@ package roku
 
 import (
 	"fmt"
-	"log"
 	"net/url"
+	"slices"
+	"time"
 
 	"github.com/grahamplata/roku-remote/roku/api"
 	"github.com/koron/go-ssdp"
@@ -12,8 +13,9 @@ import (
 // RokuIdentifier is the string to look for via ssdp
 const RokuIdentifier = "roku:ecp"
 
-// Find searches for Roku devices on the local network
-func Find(ScanDuration int) (devices []Device, err error) {
+// Find searches for Roku devices on the local network. The options are
+// applied to every device found.
+func Find(ScanDuration int, opts ...Option) (devices []Device, err error) {
 	// Validate scan duration is within reasonable bounds
 	if ScanDuration < 1 {
 		return nil, fmt.Errorf("scan duration must be at least 1 second, got %d", ScanDuration)
@@ -22,24 +24,28 @@ func Find(ScanDuration int) (devices []Device, err error) {
 		return nil, fmt.Errorf("scan duration must be at most 60 seconds, got %d", ScanDuration)
 	}
 
+	logger := newOptions(opts).logger
+	start := time.Now()
 	found, err := ssdp.Search(RokuIdentifier, ScanDuration, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to search for Roku devices: %w", err)
 	}
+	logger.Debug("ssdp search complete", "target", RokuIdentifier, "responses", len(found), "latency", time.Since(start))
 	for _, device := range found {
 		location, err := url.Parse(device.Location)
 		if err != nil {
-			log.Printf("Failed to parse device location %s: %v", device.Location, err)
+			logger.Warn("failed to parse device location", "location", device.Location, "error", err)
 			continue
 		}
-		r := NewDevice(location.Hostname())
+		r := NewDevice(location.Hostname(), opts...)
 		devices = append(devices, *r)
-		log.Printf("Found Roku device at %s", location.Hostname())
+		logger.Debug("found roku device", "device", location.Hostname(), "usn", device.USN, "server", device.Server)
 	}
 	return devices, nil
 }
 
-// AvailableActions returns the available actions
-func AvailableActions() map[string]string {
-	return api.ExternalControlActions
+// AvailableActions returns every key that can be sent with Device.Action,
+// in a stable order. The slice is a copy the caller may modify.
+func AvailableActions() []api.KeySpec {
+	return slices.Clone(api.Keys)
 }
@@ -3,36 +3,52 @@ package roku
 import (
 	"testing"
 
+	"github.com/grahamplata/roku-remote/roku/api"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestAvailableActions(t *testing.T) {
 	actions := AvailableActions()
 
-	// Verify the map is not empty
+	// Verify the list is not empty
 	assert.NotEmpty(t, actions)
 
 	// Verify some known actions exist
-	expectedActions := map[string]string{
-		"home":       "/Home",
-		"select":     "/Select",
-		"up":         "/Up",
-		"down":       "/Down",
-		"left":       "/Left",
-		"right":      "/Right",
-		"volumeup":   "/VolumeUp",
-		"volumedown": "/VolumeDown",
-		"mute":       "/VolumeMute",
-		"poweroff":   "/PowerOff",
-		"HDMI1":      "/InputHDMI1",
+	expectedActions := map[string]api.Key{
+		"home":       api.KeyHome,
+		"select":     api.KeySelect,
+		"up":         api.KeyUp,
+		"down":       api.KeyDown,
+		"left":       api.KeyLeft,
+		"right":      api.KeyRight,
+		"volumeup":   api.KeyVolumeUp,
+		"volumedown": api.KeyVolumeDown,
+		"mute":       api.KeyVolumeMute,
+		"poweroff":   api.KeyPowerOff,
+		"poweron":    api.KeyPowerOn,
+		"hdmi1":      api.KeyInputHDMI1,
+		"av1":        api.KeyInputAV1,
+		"sleep":      api.KeySleep,
 	}
 
-	for action, expectedPath := range expectedActions {
-		actualPath, exists := actions[action]
+	byName := make(map[string]api.Key)
+	for _, action := range actions {
+		byName[action.Name] = action.Key
+	}
+	for action, expectedKey := range expectedActions {
+		actualKey, exists := byName[action]
 		assert.True(t, exists, "Expected action %q to exist", action)
-		assert.Equal(t, expectedPath, actualPath, "Action %q should map to %q", action, expectedPath)
+		assert.Equal(t, expectedKey, actualKey, "Action %q should map to %q", action, expectedKey)
 	}
 
-	// Verify total count (as of current implementation, there are 27 actions)
-	assert.Equal(t, 27, len(actions), "Expected 27 total actions")
+	// Verify total count (as of current implementation, there are 31 actions)
+	assert.Equal(t, 31, len(actions), "Expected 31 total actions")
+
+	// The order is stable between calls
+	assert.Equal(t, actions, AvailableActions())
+	assert.Equal(t, "home", actions[0].Name)
+
+	// Callers get their own copy
+	actions[0].Name = "changed"
+	assert.Equal(t, "home", AvailableActions()[0].Name)
 }
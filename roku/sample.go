@@ -0,0 +1,113 @@
+package roku
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku/api"
+)
+
+// DefaultSampleInterval is how often a Sampler samples its devices
+const DefaultSampleInterval = 30 * time.Second
+
+// Sample is what a device was doing at one point in time
+type Sample struct {
+	// On is false while the device is in standby, leaving App and Player empty
+	On bool
+	// App is the active app, with an empty ID on the home screen
+	App api.App
+	// Player is the media player while an app is open, nil when it couldn't
+	// be read
+	Player *api.Player
+}
+
+// Sample reads the power mode, the active app and, while an app is open,
+// the media player. The player is optional and its errors are ignored.
+func (d *Device) Sample(ctx context.Context) (Sample, error) {
+	info, err := d.DeviceInfo(ctx)
+	if err != nil {
+		return Sample{}, err
+	}
+	if info.PowerMode != "" && info.PowerMode != "PowerOn" {
+		return Sample{}, nil
+	}
+	active, err := d.ActiveApp(ctx)
+	if err != nil {
+		return Sample{}, err
+	}
+	s := Sample{On: true, App: active.App}
+	if s.App.ID == "" {
+		return s, nil
+	}
+	if player, err := d.Player(ctx); err == nil {
+		s.Player = player
+	}
+	return s, nil
+}
+
+// DeviceSample is one device's result in a round of samples
+type DeviceSample struct {
+	Device *Device
+	Sample Sample
+	// Err is set when the device couldn't be read
+	Err error
+}
+
+// SampleAll samples every device concurrently and returns the results in
+// the order of devices
+func SampleAll(ctx context.Context, devices []*Device) []DeviceSample {
+	samples := make([]DeviceSample, len(devices))
+	var wg sync.WaitGroup
+	for i, device := range devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := device.Sample(ctx)
+			samples[i] = DeviceSample{Device: device, Sample: s, Err: err}
+		}()
+	}
+	wg.Wait()
+	return samples
+}
+
+// Observer receives each round of samples taken by a Sampler
+type Observer func(ctx context.Context, samples []DeviceSample, now time.Time) error
+
+// Sampler samples devices every Interval and hands each round to every
+// observer, so features that follow the same devices share one poll
+type Sampler struct {
+	Devices []*Device
+	// Interval between rounds, DefaultSampleInterval when zero
+	Interval time.Duration
+	// Observers are called in order with every round. An error stops Run.
+	Observers []Observer
+}
+
+// Run samples every Interval until ctx is cancelled or an observer fails
+func (s *Sampler) Run(ctx context.Context) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultSampleInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Round(0) uses the wall clock so gaps while asleep are detected
+		now := time.Now().Round(0)
+		samples := SampleAll(ctx, s.Devices)
+		if ctx.Err() != nil {
+			return nil
+		}
+		for _, observe := range s.Observers {
+			if err := observe(ctx, samples, now); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
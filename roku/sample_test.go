@@ -0,0 +1,95 @@
+package roku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevice_Sample(t *testing.T) {
+	power := "PowerOn"
+	app := `<app id="12">Netflix</app>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case api.EndpointDeviceInfo:
+			fmt.Fprintf(w, `<device-info><power-mode>%s</power-mode></device-info>`, power)
+		case api.EndpointActiveApp:
+			fmt.Fprintf(w, `<active-app>%s</active-app>`, app)
+		case api.EndpointMediaPlayer:
+			fmt.Fprint(w, `<player error="false" state="play"/>`)
+		}
+	}))
+	defer server.Close()
+	device := createTestDevice(server)
+	ctx := context.Background()
+
+	s, err := device.Sample(ctx)
+	require.NoError(t, err)
+	assert.True(t, s.On)
+	assert.Equal(t, "Netflix", s.App.Name)
+	require.NotNil(t, s.Player)
+	assert.Equal(t, "play", s.Player.State)
+
+	app = `<app>Roku</app>`
+	s, err = device.Sample(ctx)
+	require.NoError(t, err)
+	assert.True(t, s.On)
+	assert.Empty(t, s.App.ID)
+	assert.Nil(t, s.Player)
+
+	power = "DisplayOff"
+	s, err = device.Sample(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Sample{}, s)
+}
+
+func TestSampler_Run(t *testing.T) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case api.EndpointDeviceInfo:
+			polls.Add(1)
+			fmt.Fprint(w, `<device-info><power-mode>PowerOn</power-mode></device-info>`)
+		case api.EndpointActiveApp:
+			fmt.Fprint(w, `<active-app><app>Roku</app></active-app>`)
+		}
+	}))
+	defer server.Close()
+
+	// Both observers see every round from a single poll of the device
+	var first, second atomic.Int32
+	stop := errors.New("stop")
+	sampler := &Sampler{
+		Devices:  []*Device{createTestDevice(server)},
+		Interval: 10 * time.Millisecond,
+		Observers: []Observer{
+			func(ctx context.Context, samples []DeviceSample, now time.Time) error {
+				require.Len(t, samples, 1)
+				assert.NoError(t, samples[0].Err)
+				first.Add(1)
+				return nil
+			},
+			func(ctx context.Context, samples []DeviceSample, now time.Time) error {
+				if second.Add(1) == 3 {
+					return stop
+				}
+				return nil
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.ErrorIs(t, sampler.Run(ctx), stop)
+	assert.Equal(t, int32(3), first.Load())
+	assert.Equal(t, int32(3), polls.Load())
+}
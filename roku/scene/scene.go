@@ -0,0 +1,309 @@
+package scene
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+)
+
+// DefaultPause is the delay between repeated keypresses
+const DefaultPause = roku.DefaultKeyPause
+
+// MaxRepeat bounds the repeat count of a key step
+const MaxRepeat = 100
+
+// Step is one action in a scene, parsed from one of
+//
+//	<key> [xN]        press a key N times, such as volumedown x20
+//	launch <app>      launch an installed app by id or name
+//	input <name>      switch to a TV input by label or port, see roku.Device.FindInput
+//	volume <level>    set an absolute volume, see roku.Device.SetVolume
+//	wait <duration>   pause, such as wait 5s after poweron
+type Step struct {
+	// Spec is the step as written
+	Spec string
+	// Action is a key name from roku.AvailableActions, "launch", "input",
+	// "volume" or "wait"
+	Action string
+	// Key is the key a key step presses
+	Key api.Key
+	// Repeat is how many times a key is pressed
+	Repeat int
+	// App is the app id or name to launch, or the input to switch to
+	App string
+	// Level is the volume a volume step sets
+	Level int
+	// Wait is how long a wait step pauses
+	Wait time.Duration
+}
+
+// ParseStep parses a step as written in the config file
+func ParseStep(spec string) (Step, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return Step{}, errors.New("empty step")
+	}
+	step := Step{Spec: strings.Join(fields, " "), Action: fields[0], Repeat: 1}
+	switch strings.ToLower(fields[0]) {
+	case "launch":
+		if len(fields) < 2 {
+			return Step{}, fmt.Errorf("launch needs an app id or name in %q", spec)
+		}
+		step.Action = "launch"
+		step.App = strings.Join(fields[1:], " ")
+		return step, nil
+	case "input":
+		if len(fields) < 2 {
+			return Step{}, fmt.Errorf("input needs a label or port in %q", spec)
+		}
+		step.Action = "input"
+		step.App = strings.Join(fields[1:], " ")
+		return step, nil
+	case "volume":
+		if len(fields) != 2 {
+			return Step{}, fmt.Errorf("volume needs one level such as 20 in %q", spec)
+		}
+		level, err := strconv.Atoi(fields[1])
+		if err != nil || level < 0 {
+			return Step{}, fmt.Errorf("invalid volume %q, expected a level such as 20", fields[1])
+		}
+		step.Action = "volume"
+		step.Level = level
+		return step, nil
+	case "wait":
+		if len(fields) != 2 {
+			return Step{}, fmt.Errorf("wait needs one duration such as 5s in %q", spec)
+		}
+		d, err := time.ParseDuration(fields[1])
+		if err != nil || d <= 0 {
+			return Step{}, fmt.Errorf("invalid wait %q, expected a duration such as 5s", fields[1])
+		}
+		step.Action = "wait"
+		step.Wait = d
+		return step, nil
+	}
+
+	key, err := api.ParseKey(step.Action)
+	if err != nil {
+		return Step{}, fmt.Errorf("unknown action %q in %q", step.Action, spec)
+	}
+	keySpec, _ := key.Spec()
+	step.Action, step.Key = keySpec.Name, key
+	switch len(fields) {
+	case 1:
+	case 2:
+		n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(fields[1]), "x"))
+		if !strings.HasPrefix(strings.ToLower(fields[1]), "x") || err != nil || n < 1 || n > MaxRepeat {
+			return Step{}, fmt.Errorf("invalid repeat %q, expected x1 to x%d", fields[1], MaxRepeat)
+		}
+		step.Repeat = n
+	default:
+		return Step{}, fmt.Errorf("unexpected %q after %s", strings.Join(fields[2:], " "), step.Action)
+	}
+	return step, nil
+}
+
+// Part is the steps a scene runs on one device, in order
+type Part struct {
+	// Device is a name from roku.names or an IP
+	Device string
+	Steps  []Step
+}
+
+// Scene is a named preset of steps across devices
+type Scene struct {
+	Name  string
+	Parts []Part
+}
+
+// Entry is one device's steps as stored in the config file. Scenes are
+// stored as lists of entries because viper splits map keys on dots, which
+// would break devices given by IP.
+type Entry struct {
+	Device string   `mapstructure:"device" yaml:"device"`
+	Steps  []string `mapstructure:"steps" yaml:"steps"`
+}
+
+// Parse builds a scene from its config entries. Parts keep the order of the
+// entries.
+func Parse(name string, entries []Entry) (Scene, error) {
+	scene := Scene{Name: name}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		device := strings.TrimSpace(entry.Device)
+		if device == "" {
+			return Scene{}, fmt.Errorf("scene %s has an entry without a device", name)
+		}
+		if seen[strings.ToLower(device)] {
+			return Scene{}, fmt.Errorf("scene %s lists device %s more than once", name, device)
+		}
+		seen[strings.ToLower(device)] = true
+		part := Part{Device: device}
+		for _, spec := range entry.Steps {
+			step, err := ParseStep(spec)
+			if err != nil {
+				return Scene{}, fmt.Errorf("scene %s, device %s: %w", name, device, err)
+			}
+			part.Steps = append(part.Steps, step)
+		}
+		scene.Parts = append(scene.Parts, part)
+	}
+	if len(scene.Parts) == 0 {
+		return Scene{}, fmt.Errorf("scene %s has no devices", name)
+	}
+	return scene, nil
+}
+
+// Result is the outcome of one step
+type Result struct {
+	Device string
+	Step   Step
+	// Err is set when the step failed
+	Err error
+	// Skipped is set for steps not run because an earlier step on the same
+	// device failed
+	Skipped bool
+}
+
+// Runner runs scenes
+type Runner struct {
+	// Resolve returns the device for a part's device name
+	Resolve func(name string) (*roku.Device, error)
+	// Pause between repeated keypresses, DefaultPause when zero
+	Pause time.Duration
+	// VolumeSteps returns the calibrated volume steps for a part's device
+	// name, roku.DefaultVolumeSteps when nil or zero
+	VolumeSteps func(name string) int
+	// Report is called as each step finishes, possibly concurrently
+	Report func(Result)
+}
+
+// Run runs the parts of a scene concurrently, each device's steps in order.
+// A failed step skips the rest of that device's steps but not other devices.
+// The returned error joins every failure.
+func (r *Runner) Run(ctx context.Context, scene Scene) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, part := range scene.Parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.runPart(ctx, part); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", part.Device, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+func (r *Runner) runPart(ctx context.Context, part Part) error {
+	device, err := r.Resolve(part.Device)
+	if err != nil {
+		// Fail the first step and skip the rest so every step is reported
+		if len(part.Steps) > 0 {
+			r.report(Result{Device: part.Device, Step: part.Steps[0], Err: err})
+			r.skip(part, 1)
+		}
+		return err
+	}
+	for i, step := range part.Steps {
+		if err := r.runStep(ctx, device, part.Device, step); err != nil {
+			r.report(Result{Device: part.Device, Step: step, Err: err})
+			r.skip(part, i+1)
+			return fmt.Errorf("%s: %w", step.Spec, err)
+		}
+		r.report(Result{Device: part.Device, Step: step})
+	}
+	return nil
+}
+
+// skip reports the steps of part from index on as skipped
+func (r *Runner) skip(part Part, from int) {
+	for _, step := range part.Steps[from:] {
+		r.report(Result{Device: part.Device, Step: step, Skipped: true})
+	}
+}
+
+func (r *Runner) runStep(ctx context.Context, device *roku.Device, name string, step Step) error {
+	switch step.Action {
+	case "wait":
+		return sleep(ctx, step.Wait)
+	case "launch":
+		app, err := device.FindApp(ctx, step.App)
+		if err != nil {
+			return err
+		}
+		return device.Launch(ctx, app.ID)
+	case "input":
+		input, err := device.FindInput(ctx, step.App)
+		if err != nil {
+			return err
+		}
+		return device.SetInput(ctx, *input)
+	case "volume":
+		if err := checkKey(ctx, device, api.KeyVolumeDown); err != nil {
+			return err
+		}
+		steps := 0
+		if r.VolumeSteps != nil {
+			steps = r.VolumeSteps(name)
+		}
+		if steps <= 0 {
+			steps = roku.DefaultVolumeSteps
+		}
+		return device.SetVolume(ctx, step.Level, steps, r.pause())
+	}
+	if err := checkKey(ctx, device, step.Key); err != nil {
+		return err
+	}
+	return device.Press(ctx, step.Key, step.Repeat, r.pause())
+}
+
+// checkKey fails steps whose key the device model ignores. Other errors are
+// left for the keypress to report.
+func checkKey(ctx context.Context, device *roku.Device, key api.Key) error {
+	if err := device.CheckKey(ctx, key); errors.Is(err, roku.ErrUnsupported) {
+		return err
+	}
+	return nil
+}
+
+func (r *Runner) report(result Result) {
+	if r.Report != nil {
+		r.Report(result)
+	}
+}
+
+func (r *Runner) pause() time.Duration {
+	if r.Pause <= 0 {
+		return DefaultPause
+	}
+	return r.Pause
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,209 @@
+package scene
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// customTransport redirects device requests to the test server
+type customTransport struct {
+	serverURL string
+}
+
+func (t *customTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Host = strings.TrimPrefix(t.serverURL, "http://")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// fakeDevice serves the app list and device info, and records POSTs
+type fakeDevice struct {
+	mu    sync.Mutex
+	posts []string
+	// player makes the device report itself as a streaming player, not a TV
+	player bool
+}
+
+func (f *fakeDevice) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == api.EndpointApps {
+		_, _ = io.WriteString(w, `<apps><app id="13535">Plex - Free Movies &amp; TV</app><app id="837">YouTube</app>`+
+			`<app id="tvinput.hdmi2" type="tvin">PlayStation</app></apps>`)
+		return
+	}
+	if r.URL.Path == api.EndpointDeviceInfo {
+		if f.player {
+			_, _ = io.WriteString(w, `<device-info><friendly-model-name>Roku Ultra</friendly-model-name></device-info>`)
+			return
+		}
+		_, _ = io.WriteString(w, `<device-info><is-tv>true</is-tv></device-info>`)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.posts = append(f.posts, strings.TrimSpace(r.URL.Path+" "+string(body)))
+}
+
+func newTestDevice(t *testing.T, ip string) (*roku.Device, *fakeDevice) {
+	t.Helper()
+	fake := &fakeDevice{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return &roku.Device{
+		IP:     ip,
+		Client: api.NewClient(ip, &http.Client{Transport: &customTransport{serverURL: server.URL}}),
+	}, fake
+}
+
+func TestParseStep(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Step
+		wantErr string
+	}{
+		{spec: "poweron", want: Step{Spec: "poweron", Action: "poweron", Key: api.KeyPowerOn, Repeat: 1}},
+		{spec: "volumedown  x20", want: Step{Spec: "volumedown x20", Action: "volumedown", Key: api.KeyVolumeDown, Repeat: 20}},
+		{spec: "HDMI2", want: Step{Spec: "HDMI2", Action: "hdmi2", Key: api.KeyInputHDMI2, Repeat: 1}},
+		{spec: "launch Plex - Free Movies", want: Step{Spec: "launch Plex - Free Movies", Action: "launch", Repeat: 1, App: "Plex - Free Movies"}},
+		{spec: "volume 20", want: Step{Spec: "volume 20", Action: "volume", Repeat: 1, Level: 20}},
+		{spec: "volume loud", wantErr: "invalid volume"},
+		{spec: "wait 5s", want: Step{Spec: "wait 5s", Action: "wait", Repeat: 1, Wait: 5 * time.Second}},
+		{spec: "", wantErr: "empty step"},
+		{spec: "launch", wantErr: "launch needs an app"},
+		{spec: "input PlayStation 5", want: Step{Spec: "input PlayStation 5", Action: "input", Repeat: 1, App: "PlayStation 5"}},
+		{spec: "input", wantErr: "input needs a label"},
+		{spec: "wait soon", wantErr: "invalid wait"},
+		{spec: "dance", wantErr: "unknown action"},
+		{spec: "volumeup 5", wantErr: "invalid repeat"},
+		{spec: "volumeup x500", wantErr: "invalid repeat"},
+		{spec: "volumeup x2 now", wantErr: "unexpected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			step, err := ParseStep(tt.spec)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, step)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	scene, err := Parse("movie-night", []Entry{
+		{Device: "living-room", Steps: []string{"poweron", "HDMI2"}},
+		{Device: "192.168.1.5", Steps: []string{"poweroff"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, scene.Parts, 2)
+	assert.Equal(t, "living-room", scene.Parts[0].Device)
+	assert.Equal(t, "192.168.1.5", scene.Parts[1].Device)
+	assert.Len(t, scene.Parts[0].Steps, 2)
+
+	_, err = Parse("broken", []Entry{{Device: "bedroom", Steps: []string{"dance"}}})
+	assert.ErrorContains(t, err, "scene broken, device bedroom")
+
+	_, err = Parse("twice", []Entry{{Device: "bedroom"}, {Device: "Bedroom"}})
+	assert.ErrorContains(t, err, "more than once")
+
+	_, err = Parse("anonymous", []Entry{{Steps: []string{"home"}}})
+	assert.ErrorContains(t, err, "without a device")
+
+	_, err = Parse("empty", nil)
+	assert.ErrorContains(t, err, "no devices")
+}
+
+func TestRunner_Run(t *testing.T) {
+	living, livingFake := newTestDevice(t, "192.168.1.21")
+	bedroom, bedroomFake := newTestDevice(t, "192.168.1.22")
+	devices := map[string]*roku.Device{"living-room": living, "bedroom": bedroom}
+
+	scene, err := Parse("movie-night", []Entry{
+		{Device: "living-room", Steps: []string{"poweron", "input playstation", "volumedown x3", "volumeup x2", "launch plex - free movies & tv"}},
+		{Device: "bedroom", Steps: []string{"poweroff", "volume 1"}},
+		{Device: "kitchen", Steps: []string{"poweroff", "home"}},
+	})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var results []Result
+	runner := &Runner{
+		Resolve: func(name string) (*roku.Device, error) {
+			if d, ok := devices[name]; ok {
+				return d, nil
+			}
+			return nil, errors.New("unknown device")
+		},
+		Pause:       time.Millisecond,
+		VolumeSteps: func(name string) int { return 2 },
+		Report: func(r Result) {
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, r)
+		},
+	}
+	err = runner.Run(context.Background(), scene)
+	assert.EqualError(t, err, "kitchen: unknown device")
+
+	assert.Equal(t, []string{
+		"/keypress/PowerOn",
+		"/launch id=tvinput.hdmi2",
+		"/keypress/VolumeDown", "/keypress/VolumeDown", "/keypress/VolumeDown",
+		"/keypress/VolumeUp", "/keypress/VolumeUp",
+		"/launch id=13535",
+	}, livingFake.posts)
+	assert.Equal(t, []string{"/keypress/PowerOff", "/keypress/VolumeDown", "/keypress/VolumeDown", "/keypress/VolumeUp"}, bedroomFake.posts)
+
+	status := make(map[string]string)
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			status[r.Device+" "+r.Step.Spec] = "skipped"
+		case r.Err != nil:
+			status[r.Device+" "+r.Step.Spec] = "failed"
+		default:
+			status[r.Device+" "+r.Step.Spec] = "ok"
+		}
+	}
+	assert.Len(t, results, 9)
+	assert.Equal(t, "ok", status["living-room launch plex - free movies & tv"])
+	assert.Equal(t, "ok", status["bedroom poweroff"])
+	assert.Equal(t, "failed", status["kitchen poweroff"])
+	assert.Equal(t, "skipped", status["kitchen home"])
+}
+
+func TestRunner_Unsupported(t *testing.T) {
+	device, fake := newTestDevice(t, "192.168.1.23")
+	fake.player = true
+
+	scene, err := Parse("bedtime", []Entry{{Device: "office", Steps: []string{"home", "poweroff", "back"}}})
+	require.NoError(t, err)
+
+	var results []Result
+	runner := &Runner{
+		Resolve: func(string) (*roku.Device, error) { return device, nil },
+		Pause:   time.Millisecond,
+		Report:  func(r Result) { results = append(results, r) },
+	}
+	err = runner.Run(context.Background(), scene)
+	assert.ErrorIs(t, err, roku.ErrUnsupported)
+	assert.EqualError(t, err, "office: poweroff: poweroff is not supported by Roku Ultra: it can't be turned off over ECP")
+	assert.Equal(t, []string{"/keypress/Home"}, fake.posts)
+	require.Len(t, results, 3)
+	assert.True(t, results[2].Skipped)
+}
@@ -0,0 +1,193 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku/api"
+)
+
+// Days is a set of weekdays indexed by time.Weekday
+type Days [7]bool
+
+var (
+	everyDay = Days{true, true, true, true, true, true, true}
+	weekdays = Days{false, true, true, true, true, true, false}
+	weekends = Days{true, false, false, false, false, false, true}
+)
+
+// String renders the set the way it is written in a rule
+func (d Days) String() string {
+	switch d {
+	case everyDay:
+		return "day"
+	case weekdays:
+		return "weekday"
+	case weekends:
+		return "weekend"
+	}
+	var names []string
+	for day, set := range d {
+		if set {
+			names = append(names, strings.ToLower(time.Weekday(day).String()[:3]))
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseDays parses day, weekday, weekend or a comma separated list of day
+// names such as mon,wed,fri
+func ParseDays(s string) (Days, error) {
+	switch strings.ToLower(s) {
+	case "day", "days", "daily":
+		return everyDay, nil
+	case "weekday", "weekdays":
+		return weekdays, nil
+	case "weekend", "weekends":
+		return weekends, nil
+	}
+	var days Days
+	for _, name := range strings.Split(strings.ToLower(s), ",") {
+		found := false
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			full := strings.ToLower(day.String())
+			if name == full || (len(name) >= 3 && strings.HasPrefix(full, name)) {
+				days[day] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return Days{}, fmt.Errorf("unknown day %q, expected day, weekday, weekend or names such as mon,wed", name)
+		}
+	}
+	return days, nil
+}
+
+// Rule is a parsed schedule entry such as
+//
+//	22:30 poweroff --device kids
+//	every weekday 07:00 launch news
+//
+// Rules without "every" run once, at the first matching time after they
+// were created.
+type Rule struct {
+	// Spec is the rule as written, which also identifies it
+	Spec string
+	// Repeat is set for "every" rules
+	Repeat bool
+	// Days the rule runs on, every day for one-shot rules
+	Days Days
+	// Hour and Minute of the day the rule runs at, in local time
+	Hour   int
+	Minute int
+	// Action is a key name such as poweroff, or launch
+	Action string
+	// Args to the action, the app for launch
+	Args []string
+	// Device is a device name or IP, the default device when empty
+	Device string
+	// Created is when the rule was added, from which one-shot rules are timed
+	Created time.Time
+}
+
+// Parse parses a rule specification
+func Parse(spec string) (Rule, error) {
+	rule := Rule{Spec: strings.TrimSpace(spec), Days: everyDay}
+	fields := strings.Fields(spec)
+
+	// Pull out the device flag wherever it appears
+	var rest []string
+	for i := 0; i < len(fields); i++ {
+		switch {
+		case fields[i] == "--device" || fields[i] == "-d":
+			if i+1 >= len(fields) {
+				return Rule{}, fmt.Errorf("%s requires a device name or IP", fields[i])
+			}
+			rule.Device = fields[i+1]
+			i++
+		case strings.HasPrefix(fields[i], "--device="):
+			rule.Device = strings.TrimPrefix(fields[i], "--device=")
+		default:
+			rest = append(rest, fields[i])
+		}
+	}
+
+	if len(rest) > 0 && strings.EqualFold(rest[0], "every") {
+		if len(rest) < 2 {
+			return Rule{}, fmt.Errorf("every requires days, such as every weekday")
+		}
+		days, err := ParseDays(rest[1])
+		if err != nil {
+			return Rule{}, err
+		}
+		rule.Repeat = true
+		rule.Days = days
+		rest = rest[2:]
+	}
+
+	if len(rest) < 2 {
+		return Rule{}, fmt.Errorf("invalid rule %q, expected [every <days>] HH:MM <action> [args] [--device <name>]", spec)
+	}
+	hour, minute, err := parseClock(rest[0])
+	if err != nil {
+		return Rule{}, err
+	}
+	rule.Hour, rule.Minute = hour, minute
+
+	rule.Action = strings.ToLower(rest[1])
+	rule.Args = rest[2:]
+	switch rule.Action {
+	case "launch":
+		if len(rule.Args) == 0 {
+			return Rule{}, fmt.Errorf("launch requires an app name or id")
+		}
+	default:
+		key, err := api.ParseKey(rule.Action)
+		if err != nil {
+			return Rule{}, fmt.Errorf("unknown action %q, expected launch or a key such as poweroff", rule.Action)
+		}
+		spec, _ := key.Spec()
+		rule.Action = spec.Name
+		if len(rule.Args) > 0 {
+			return Rule{}, fmt.Errorf("action %s takes no arguments", rule.Action)
+		}
+	}
+	return rule, nil
+}
+
+// parseClock parses a 24 hour HH:MM time of day
+func parseClock(s string) (int, int, error) {
+	hh, mm, ok := strings.Cut(s, ":")
+	if ok {
+		hour, errHour := strconv.Atoi(hh)
+		minute, errMinute := strconv.Atoi(mm)
+		if errHour == nil && errMinute == nil && hour >= 0 && hour < 24 && minute >= 0 && minute < 60 && len(mm) == 2 {
+			return hour, minute, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid time %q, expected 24 hour HH:MM", s)
+}
+
+// Next returns the first time the rule runs strictly after t, in t's location
+func (r Rule) Next(t time.Time) time.Time {
+	for day := 0; day <= 7; day++ {
+		candidate := time.Date(t.Year(), t.Month(), t.Day()+day, r.Hour, r.Minute, 0, 0, t.Location())
+		if candidate.After(t) && r.Days[candidate.Weekday()] {
+			return candidate
+		}
+	}
+	// Unreachable for a rule with at least one day set
+	return time.Time{}
+}
+
+// Command describes the action, such as "launch news on kids"
+func (r Rule) Command() string {
+	s := strings.Join(append([]string{r.Action}, r.Args...), " ")
+	if r.Device != "" {
+		s += " on " + r.Device
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("One-shot with device", func(t *testing.T) {
+		rule, err := Parse("22:30 poweroff --device kids")
+
+		require.NoError(t, err)
+		assert.False(t, rule.Repeat)
+		assert.Equal(t, 22, rule.Hour)
+		assert.Equal(t, 30, rule.Minute)
+		assert.Equal(t, "poweroff", rule.Action)
+		assert.Equal(t, "kids", rule.Device)
+		assert.Equal(t, "poweroff on kids", rule.Command())
+	})
+
+	t.Run("Repeating launch", func(t *testing.T) {
+		rule, err := Parse("every weekday 07:00 launch news")
+
+		require.NoError(t, err)
+		assert.True(t, rule.Repeat)
+		assert.Equal(t, "weekday", rule.Days.String())
+		assert.Equal(t, "launch", rule.Action)
+		assert.Equal(t, []string{"news"}, rule.Args)
+		assert.Empty(t, rule.Device)
+	})
+
+	t.Run("Day list", func(t *testing.T) {
+		rule, err := Parse("every mon,wednesday,fri 18:05 home --device=192.168.1.10")
+
+		require.NoError(t, err)
+		assert.Equal(t, "mon,wed,fri", rule.Days.String())
+		assert.Equal(t, "192.168.1.10", rule.Device)
+	})
+
+	invalid := []string{
+		"",
+		"poweroff",
+		"25:00 poweroff",
+		"7:5 poweroff",
+		"22:30 explode",
+		"22:30 launch",
+		"22:30 poweroff now",
+		"every someday 07:00 home",
+		"22:30 poweroff --device",
+	}
+	for _, spec := range invalid {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestRule_Next(t *testing.T) {
+	// Friday 2026-10-16
+	friday := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+
+	weekday, err := Parse("every weekday 07:00 home")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC), weekday.Next(friday), "skips the weekend")
+
+	daily, err := Parse("22:30 poweroff")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 16, 22, 30, 0, 0, time.UTC), daily.Next(friday), "later the same day")
+	assert.Equal(t, time.Date(2026, 10, 17, 22, 30, 0, 0, time.UTC), daily.Next(daily.Next(friday)), "strictly after")
+}
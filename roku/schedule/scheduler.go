@@ -0,0 +1,242 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// DefaultInterval is how often the scheduler checks for due rules
+const DefaultInterval = 30 * time.Second
+
+// DefaultGrace is how late a rule may run after its scheduled time, for
+// example after the machine wakes from sleep. Older runs are logged as missed.
+const DefaultGrace = 15 * time.Minute
+
+// Run statuses recorded in the run log
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+	StatusMissed = "missed"
+)
+
+// Record is an entry in the run log
+type Record struct {
+	Time      time.Time `json:"time"`
+	Rule      string    `json:"rule"`
+	Scheduled time.Time `json:"scheduled"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// state is persisted between runs so that rules due while the scheduler was
+// stopped are caught up on restart
+type state struct {
+	LastCheck time.Time `json:"last_check"`
+}
+
+// Scheduler runs rules at their scheduled times. Times are compared against
+// the wall clock on every check rather than with timers, so rules that fell
+// due while the machine was asleep run when it wakes, within Grace.
+type Scheduler struct {
+	// Rules returns the current rules, called on every check so that changes
+	// take effect without a restart. When it fails, the last rules loaded are
+	// used instead.
+	Rules func() ([]Rule, error)
+	// Exec performs a rule's action
+	Exec func(context.Context, Rule) error
+	// Done is called once a one-shot rule has run or been missed, and again
+	// on later checks if the rule is still returned, optional. One-shot rules
+	// only run once whether or not Done removes them.
+	Done func(Rule) error
+	// Interval between checks, DefaultInterval when zero
+	Interval time.Duration
+	// Grace is how late a rule may still run, DefaultGrace when zero
+	Grace time.Duration
+	// StatePath persists the last check time, optional
+	StatePath string
+	// Log receives a JSON Record per run, optional
+	Log io.Writer
+	// Logger records rules that couldn't be loaded or removed, discarded
+	// when nil
+	Logger *slog.Logger
+
+	lastCheck time.Time
+	// rules are the last rules Rules returned without error
+	rules  []Rule
+	loaded bool
+	// logged holds the one-shot rules still listed because Done failed, so
+	// they aren't logged again
+	logged map[string]bool
+}
+
+// Run checks for due rules every Interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if err := s.loadState(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Round(0) drops the monotonic reading so the wall clock is used,
+		// which keeps advancing while the machine sleeps
+		if err := s.Tick(ctx, time.Now().Round(0)); err != nil {
+			s.logger().Warn("schedule check failed", "error", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Tick runs every rule that fell due since the previous tick. On the first
+// tick without saved state nothing in the past is run. If the rules can't
+// be loaded, for example while the config file is being edited, the last
+// rules loaded are used, and the tick is skipped when there are none yet.
+// The error is from saving the state, after the due rules have run.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) error {
+	if s.lastCheck.IsZero() {
+		s.lastCheck = now
+	}
+	grace := s.Grace
+	if grace <= 0 {
+		grace = DefaultGrace
+	}
+
+	if rules, err := s.Rules(); err != nil {
+		s.logger().Warn("failed to load schedule, using the last rules loaded", "error", err)
+		if !s.loaded {
+			// lastCheck stays put so rules due meanwhile run once loaded
+			return nil
+		}
+	} else {
+		s.rules, s.loaded = rules, true
+	}
+	for _, rule := range s.rules {
+		scheduled, ok := s.due(rule, now)
+		if !ok {
+			// A one-shot that already ran is still listed if Done failed, and
+			// one first loaded after its time never ran
+			if !rule.Repeat && s.Done != nil && !s.next(rule, now).After(s.lastCheck) {
+				if !s.logged[rule.Spec] {
+					s.log(Record{Time: now, Rule: rule.Spec, Scheduled: s.next(rule, now), Status: StatusMissed})
+				}
+				s.done(rule)
+			}
+			continue
+		}
+		record := Record{Rule: rule.Spec, Scheduled: scheduled}
+		if now.Sub(scheduled) > grace {
+			record.Status = StatusMissed
+		} else if err := s.Exec(ctx, rule); err != nil {
+			record.Status = StatusFailed
+			record.Error = err.Error()
+		} else {
+			record.Status = StatusOK
+		}
+		record.Time = now
+		s.log(record)
+		if !rule.Repeat && s.Done != nil {
+			s.done(rule)
+		}
+	}
+
+	s.lastCheck = now
+	return s.saveState()
+}
+
+// due returns the most recent time rule was scheduled at since the last
+// check, if any. Only the latest occurrence is returned when several were
+// missed, so a long sleep doesn't replay a backlog of runs.
+func (s *Scheduler) due(rule Rule, now time.Time) (time.Time, bool) {
+	if !rule.Repeat {
+		at := s.next(rule, now)
+		return at, at.After(s.lastCheck) && !at.After(now)
+	}
+	at := rule.Next(s.lastCheck.In(now.Location()))
+	if at.After(now) {
+		return time.Time{}, false
+	}
+	for next := rule.Next(at); !next.After(now); next = rule.Next(next) {
+		at = next
+	}
+	return at, true
+}
+
+// next returns when a one-shot rule is scheduled
+func (s *Scheduler) next(rule Rule, now time.Time) time.Time {
+	return rule.Next(rule.Created.In(now.Location()))
+}
+
+// done reports a completed one-shot rule, logging failures since the rule
+// won't run again either way
+func (s *Scheduler) done(rule Rule) {
+	if err := s.Done(rule); err != nil {
+		s.logger().Warn("failed to remove completed rule", "rule", rule.Spec, "error", err)
+		if s.logged == nil {
+			s.logged = make(map[string]bool)
+		}
+		s.logged[rule.Spec] = true
+		return
+	}
+	delete(s.logged, rule.Spec)
+}
+
+func (s *Scheduler) logger() *slog.Logger {
+	if s.Logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return s.Logger
+}
+
+func (s *Scheduler) log(record Record) {
+	if s.Log == nil {
+		return
+	}
+	_ = json.NewEncoder(s.Log).Encode(record)
+}
+
+func (s *Scheduler) loadState() error {
+	if s.StatePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read scheduler state: %w", err)
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("failed to parse scheduler state %s: %w", s.StatePath, err)
+	}
+	s.lastCheck = st.LastCheck
+	return nil
+}
+
+func (s *Scheduler) saveState() error {
+	if s.StatePath == "" {
+		return nil
+	}
+	data, err := json.Marshal(state{LastCheck: s.lastCheck})
+	if err != nil {
+		return fmt.Errorf("failed to encode scheduler state: %w", err)
+	}
+	if err := os.WriteFile(s.StatePath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write scheduler state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, spec string) Rule {
+	t.Helper()
+	rule, err := Parse(spec)
+	require.NoError(t, err)
+	return rule
+}
+
+func records(t *testing.T, log *bytes.Buffer) []Record {
+	t.Helper()
+	var out []Record
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var r Record
+		require.NoError(t, json.Unmarshal([]byte(line), &r))
+		out = append(out, r)
+	}
+	log.Reset()
+	return out
+}
+
+func TestScheduler_Tick(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 16, 6, 0, 0, 0, time.UTC)
+	morning := mustParse(t, "every day 07:00 home")
+	failing := mustParse(t, "every day 07:00 launch missing")
+	once := mustParse(t, "22:30 poweroff")
+	once.Created = start
+
+	var ran []string
+	var done []string
+	var log bytes.Buffer
+	rules := []Rule{morning, failing, once}
+	s := &Scheduler{
+		Rules: func() ([]Rule, error) { return rules, nil },
+		Exec: func(_ context.Context, r Rule) error {
+			ran = append(ran, r.Spec)
+			if r.Action == "launch" {
+				return errors.New("app is not installed")
+			}
+			return nil
+		},
+		Done: func(r Rule) error {
+			done = append(done, r.Spec)
+			return nil
+		},
+		Grace:     15 * time.Minute,
+		StatePath: filepath.Join(t.TempDir(), "state.json"),
+		Log:       &log,
+	}
+
+	// Nothing is due yet
+	require.NoError(t, s.Tick(ctx, start))
+	assert.Empty(t, ran)
+
+	// Both 07:00 rules run once, one fails
+	require.NoError(t, s.Tick(ctx, start.Add(time.Hour+30*time.Second)))
+	require.NoError(t, s.Tick(ctx, start.Add(time.Hour+time.Minute)))
+	assert.Equal(t, []string{morning.Spec, failing.Spec}, ran)
+	logged := records(t, &log)
+	require.Len(t, logged, 2)
+	assert.Equal(t, StatusOK, logged[0].Status)
+	assert.Equal(t, StatusFailed, logged[1].Status)
+	assert.Equal(t, "app is not installed", logged[1].Error)
+	assert.Equal(t, time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC), logged[0].Scheduled)
+
+	// Waking up 10 minutes after the one-shot was due catches it up
+	ran = nil
+	rules = []Rule{once}
+	require.NoError(t, s.Tick(ctx, time.Date(2026, 10, 16, 22, 40, 0, 0, time.UTC)))
+	assert.Equal(t, []string{once.Spec}, ran)
+	assert.Equal(t, []string{once.Spec}, done)
+	records(t, &log)
+
+	// After sleeping through two mornings only the latest is considered, and
+	// it is too late to run
+	ran = nil
+	rules = []Rule{morning}
+	require.NoError(t, s.Tick(ctx, time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)))
+	assert.Empty(t, ran)
+	logged = records(t, &log)
+	require.Len(t, logged, 1)
+	assert.Equal(t, StatusMissed, logged[0].Status)
+	assert.Equal(t, time.Date(2026, 10, 18, 7, 0, 0, 0, time.UTC), logged[0].Scheduled)
+
+	// A restarted scheduler resumes from the saved state
+	restarted := &Scheduler{Rules: s.Rules, Exec: s.Exec, StatePath: s.StatePath, Grace: s.Grace}
+	require.NoError(t, restarted.loadState())
+	require.NoError(t, restarted.Tick(ctx, time.Date(2026, 10, 19, 7, 5, 0, 0, time.UTC)))
+	assert.Equal(t, []string{morning.Spec}, ran)
+}
+
+func TestScheduler_OneShotRunsOnce(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC)
+	once := mustParse(t, "22:30 poweroff")
+	once.Created = start
+
+	for name, done := range map[string]func(Rule) error{
+		"NoDone":     nil,
+		"DoneFailed": func(Rule) error { return errors.New("config is read-only") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			runs := 0
+			s := &Scheduler{
+				Rules: func() ([]Rule, error) { return []Rule{once}, nil },
+				Exec:  func(context.Context, Rule) error { runs++; return nil },
+				Done:  done,
+			}
+			for minute := 0; minute <= 60; minute += 10 {
+				require.NoError(t, s.Tick(ctx, start.Add(time.Duration(minute)*time.Minute)))
+			}
+			assert.Equal(t, 1, runs)
+		})
+	}
+}
+
+func TestScheduler_StaleOneShot(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC)
+	once := mustParse(t, "22:30 poweroff")
+	once.Created = start
+
+	// The rule first loads after its time, so it never runs and is logged as
+	// missed once, however often removing it fails
+	rules := []Rule{}
+	var removed int
+	var log bytes.Buffer
+	s := &Scheduler{
+		Rules: func() ([]Rule, error) { return rules, nil },
+		Exec:  func(context.Context, Rule) error { t.Fatal("stale rule ran"); return nil },
+		Done: func(Rule) error {
+			if removed++; removed == 1 {
+				return errors.New("config is read-only")
+			}
+			return nil
+		},
+		Log: &log,
+	}
+	require.NoError(t, s.Tick(ctx, start))
+	require.NoError(t, s.Tick(ctx, start.Add(40*time.Minute)))
+	rules = []Rule{once}
+	require.NoError(t, s.Tick(ctx, start.Add(50*time.Minute)))
+	require.NoError(t, s.Tick(ctx, start.Add(60*time.Minute)))
+	assert.Equal(t, 2, removed)
+	logged := records(t, &log)
+	require.Len(t, logged, 1)
+	assert.Equal(t, StatusMissed, logged[0].Status)
+	assert.Equal(t, time.Date(2026, 10, 16, 22, 30, 0, 0, time.UTC), logged[0].Scheduled)
+}
+
+func TestScheduler_RunSaveStateFails(t *testing.T) {
+	// The state can't be written, which is logged without stopping the daemon
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	checks := 0
+	s := &Scheduler{
+		Rules: func() ([]Rule, error) {
+			if checks++; checks == 3 {
+				cancel()
+			}
+			return nil, nil
+		},
+		Exec:      func(context.Context, Rule) error { return nil },
+		Interval:  time.Millisecond,
+		StatePath: filepath.Join(t.TempDir(), "missing", "state.json"),
+	}
+	require.Error(t, s.Tick(context.Background(), time.Now()))
+	checks = 0
+	require.NoError(t, s.Run(ctx))
+	assert.Equal(t, 3, checks)
+}
+
+func TestScheduler_RulesError(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 16, 6, 0, 0, 0, time.UTC)
+	morning := mustParse(t, "every day 07:00 home")
+
+	var rules []Rule
+	var loadErr error
+	var ran []string
+	s := &Scheduler{
+		Rules: func() ([]Rule, error) { return rules, loadErr },
+		Exec:  func(_ context.Context, r Rule) error { ran = append(ran, r.Spec); return nil },
+	}
+
+	// Nothing loaded yet, so the tick is skipped without losing time
+	loadErr = errors.New("yaml: line 3: mapping values are not allowed")
+	require.NoError(t, s.Tick(ctx, start))
+	loadErr = nil
+	rules = []Rule{morning}
+	require.NoError(t, s.Tick(ctx, start.Add(time.Hour+time.Minute)))
+	assert.Equal(t, []string{morning.Spec}, ran)
+
+	// A config broken while the daemon runs keeps the last rules
+	loadErr, rules = errors.New("half edited"), nil
+	require.NoError(t, s.Tick(ctx, start.Add(25*time.Hour)))
+	assert.Equal(t, []string{morning.Spec, morning.Spec}, ran)
+}
@@ -0,0 +1,157 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DayFormat is the layout of the day keys in the store
+const DayFormat = "2006-01-02"
+
+// HomeID is the app id recorded for the home screen, which has no id in ECP
+const HomeID = "home"
+
+// AppUsage is the time spent in one app on one device on one day
+type AppUsage struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Entry is a row of a usage report
+type Entry struct {
+	Day      string
+	Device   string
+	AppID    string
+	App      string
+	Duration time.Duration
+}
+
+// Store accumulates viewing time per day, device and app in a JSON file
+type Store struct {
+	path string
+
+	mu sync.Mutex
+	// Days maps day -> device IP -> app id -> usage
+	Days map[string]map[string]map[string]*AppUsage `json:"days"`
+}
+
+// Open loads the store at path, or starts an empty one if it doesn't exist
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, Days: make(map[string]map[string]map[string]*AppUsage)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage store: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse usage store %s: %w", path, err)
+	}
+	if s.Days == nil {
+		s.Days = make(map[string]map[string]map[string]*AppUsage)
+	}
+	return s, nil
+}
+
+// Save writes the store back to its file
+func (s *Store) Save() error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode usage store: %w", err)
+	}
+	// Write then rename so a crash never leaves a truncated store
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write usage store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write usage store: %w", err)
+	}
+	return nil
+}
+
+// Add records d of viewing time on day
+func (s *Store) Add(day time.Time, device, appID, appName string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := day.Format(DayFormat)
+	devices, ok := s.Days[key]
+	if !ok {
+		devices = make(map[string]map[string]*AppUsage)
+		s.Days[key] = devices
+	}
+	apps, ok := devices[device]
+	if !ok {
+		apps = make(map[string]*AppUsage)
+		devices[device] = apps
+	}
+	app, ok := apps[appID]
+	if !ok {
+		app = &AppUsage{}
+		apps[appID] = app
+	}
+	app.Name = appName
+	app.Seconds += d.Seconds()
+}
+
+// Total returns the time a device was in use on day. An app id or name
+// limits the total to that app.
+func (s *Store) Total(day time.Time, device, app string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var seconds float64
+	for id, usage := range s.Days[day.Format(DayFormat)][device] {
+		if app == "" || strings.EqualFold(app, id) || strings.EqualFold(app, usage.Name) {
+			seconds += usage.Seconds
+		}
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Report returns the usage recorded between from and to inclusive, by day,
+// device and app, with the longest use first within each day
+func (s *Store) Report(from, to time.Time) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	first, last := from.Format(DayFormat), to.Format(DayFormat)
+	var entries []Entry
+	for day, devices := range s.Days {
+		if day < first || day > last {
+			continue
+		}
+		for device, apps := range devices {
+			for id, usage := range apps {
+				entries = append(entries, Entry{
+					Day:      day,
+					Device:   device,
+					AppID:    id,
+					App:      usage.Name,
+					Duration: time.Duration(usage.Seconds * float64(time.Second)),
+				})
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.Device != b.Device {
+			return a.Device < b.Device
+		}
+		if a.Duration != b.Duration {
+			return a.Duration > b.Duration
+		}
+		return a.AppID < b.AppID
+	})
+	return entries
+}
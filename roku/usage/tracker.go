@@ -0,0 +1,209 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+)
+
+// DefaultInterval is how often devices are sampled
+const DefaultInterval = 30 * time.Second
+
+// DefaultWarning is how long a device keeps running after the warning for
+// a reached limit before it is powered off
+const DefaultWarning = 2 * time.Minute
+
+// LimitInput is the value sent to the running channel through /input when a
+// limit is reached, for channels that choose to show a message
+const LimitInput = "screen-time-limit"
+
+// Limit is a daily screen-time allowance for a device, or for one app on a
+// device when App is set. An empty Device applies to every device.
+type Limit struct {
+	Device string
+	App    string
+	Daily  time.Duration
+}
+
+// String describes the limit, such as "2h0m0s of YouTube on 192.168.1.5"
+func (l Limit) String() string {
+	s := l.Daily.String()
+	if l.App != "" {
+		s += " of " + l.App
+	}
+	if l.Device != "" {
+		s += " on " + l.Device
+	}
+	return s
+}
+
+// Tracker adds the time between samples of the app each device shows to a
+// Store and enforces limits. It is fed by a roku.Sampler through Observe.
+//
+// When a limit is reached the running channel is sent LimitInput and the
+// device returns to the home screen. If the device is still being watched
+// Warning later, a device limit powers it off and an app limit sends it home
+// again.
+type Tracker struct {
+	Store *Store
+	// Limits returns the limits to enforce. It is called on every round so
+	// edits to the config file apply while the daemon runs. When it fails the
+	// last limits loaded are kept.
+	Limits func() ([]Limit, error)
+	// Interval between samples, DefaultInterval when zero
+	Interval time.Duration
+	// Warning between a limit being reached and power off, DefaultWarning when zero
+	Warning time.Duration
+	// Logger records enforcement, discarded when nil
+	Logger *slog.Logger
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+	warned   map[string]time.Time
+	limits   []Limit
+}
+
+// sample is the state of a device at one point in time
+type sample struct {
+	on      bool
+	appID   string
+	appName string
+}
+
+// Observe credits the app each device shows with the time since its
+// previous sample and enforces limits. Gaps longer than two intervals, such
+// as while this machine was asleep, are not counted.
+func (t *Tracker) Observe(ctx context.Context, samples []roku.DeviceSample, now time.Time) error {
+	limits := t.loadLimits()
+	var wg sync.WaitGroup
+	for _, ds := range samples {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t.observeDevice(ctx, ds, limits, now)
+		}()
+	}
+	wg.Wait()
+	return t.Store.Save()
+}
+
+func (t *Tracker) observeDevice(ctx context.Context, ds roku.DeviceSample, limits []Limit, now time.Time) {
+	device := ds.Device
+	s := sample{on: ds.Sample.On, appID: ds.Sample.App.ID, appName: ds.Sample.App.Name}
+	if s.on && s.appID == "" {
+		s.appID, s.appName = HomeID, "Home"
+	}
+
+	t.mu.Lock()
+	if t.lastSeen == nil {
+		t.lastSeen = make(map[string]time.Time)
+	}
+	last, seen := t.lastSeen[device.IP]
+	if ds.Err != nil || !s.on {
+		delete(t.lastSeen, device.IP)
+		t.mu.Unlock()
+		return
+	}
+	t.lastSeen[device.IP] = now
+	t.mu.Unlock()
+
+	if seen {
+		elapsed := now.Sub(last)
+		if max := 2 * t.interval(); elapsed > max {
+			elapsed = 0
+		}
+		if elapsed > 0 {
+			t.Store.Add(now, device.IP, s.appID, s.appName, elapsed)
+		}
+	}
+	t.enforce(ctx, device, s, limits, now)
+}
+
+// loadLimits calls Limits, falling back to the last limits loaded when it
+// fails
+func (t *Tracker) loadLimits() []Limit {
+	if t.Limits == nil {
+		return nil
+	}
+	limits, err := t.Limits()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.logger().Warn("failed to load limits, using the last limits loaded", "error", err)
+		return t.limits
+	}
+	t.limits = limits
+	return limits
+}
+
+// enforce applies the limits that cover the device and the app it shows
+func (t *Tracker) enforce(ctx context.Context, device *roku.Device, s sample, limits []Limit, now time.Time) {
+	for _, limit := range limits {
+		if limit.Device != "" && limit.Device != device.IP {
+			continue
+		}
+		if limit.App != "" && !strings.EqualFold(limit.App, s.appID) && !strings.EqualFold(limit.App, s.appName) {
+			continue
+		}
+		// The home screen doesn't count against app limits and only needs a
+		// device limit to turn it off
+		if s.appID == HomeID && limit.App != "" {
+			continue
+		}
+		if t.Store.Total(now, device.IP, limit.App) < limit.Daily {
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%s|%s", now.Format(DayFormat), device.IP, strings.ToLower(limit.App))
+		t.mu.Lock()
+		if t.warned == nil {
+			t.warned = make(map[string]time.Time)
+		}
+		warnedAt, warned := t.warned[key]
+		if !warned {
+			t.warned[key] = now
+		}
+		t.mu.Unlock()
+
+		switch {
+		case !warned:
+			t.logger().Info("screen-time limit reached, sending home", "device", device.IP, "limit", limit.String())
+			_ = device.Client.Input(ctx, LimitInput)
+			_ = device.Keypress(ctx, api.KeyHome)
+		case now.Sub(warnedAt) < t.warning():
+		case limit.App != "":
+			t.logger().Info("app limit still exceeded, sending home", "device", device.IP, "limit", limit.String())
+			_ = device.Keypress(ctx, api.KeyHome)
+		default:
+			t.logger().Info("screen-time limit exceeded, powering off", "device", device.IP, "limit", limit.String())
+			_ = device.Keypress(ctx, api.KeyPowerOff)
+		}
+	}
+}
+
+func (t *Tracker) interval() time.Duration {
+	if t.Interval <= 0 {
+		return DefaultInterval
+	}
+	return t.Interval
+}
+
+func (t *Tracker) warning() time.Duration {
+	if t.Warning <= 0 {
+		return DefaultWarning
+	}
+	return t.Warning
+}
+
+func (t *Tracker) logger() *slog.Logger {
+	if t.Logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return t.Logger
+}
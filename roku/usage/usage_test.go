@@ -0,0 +1,192 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku"
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// customTransport redirects device requests to the test server
+type customTransport struct {
+	serverURL string
+}
+
+func (t *customTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Host = strings.TrimPrefix(t.serverURL, "http://")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// fakeDevice serves device-info and active-app from settable state and
+// records POSTs
+type fakeDevice struct {
+	mu    sync.Mutex
+	power string
+	app   string
+	posts []string
+}
+
+func (f *fakeDevice) set(power, app string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.power, f.app = power, app
+}
+
+func (f *fakeDevice) takePosts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	posts := f.posts
+	f.posts = nil
+	return posts
+}
+
+func (f *fakeDevice) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case api.EndpointDeviceInfo:
+		fmt.Fprintf(w, `<device-info><power-mode>%s</power-mode></device-info>`, f.power)
+	case api.EndpointActiveApp:
+		fmt.Fprintf(w, `<active-app>%s</active-app>`, f.app)
+	case api.EndpointMediaPlayer:
+		fmt.Fprint(w, `<player error="false" state="play"/>`)
+	default:
+		body, _ := io.ReadAll(r.Body)
+		f.posts = append(f.posts, strings.TrimSpace(r.URL.Path+" "+string(body)))
+	}
+}
+
+// testTracker feeds a Tracker samples of its devices, as roku.Sampler does
+type testTracker struct {
+	*Tracker
+	devices []*roku.Device
+}
+
+func (tt *testTracker) Tick(ctx context.Context, now time.Time) error {
+	return tt.Observe(ctx, roku.SampleAll(ctx, tt.devices), now)
+}
+
+func newTestTracker(t *testing.T, limits ...Limit) (*testTracker, *fakeDevice) {
+	t.Helper()
+	fake := &fakeDevice{power: "PowerOn", app: `<app id="837">YouTube</app>`}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	device := &roku.Device{
+		IP:     "192.168.1.21",
+		Client: api.NewClient("192.168.1.21", &http.Client{Transport: &customTransport{serverURL: server.URL}}),
+	}
+	store, err := Open(filepath.Join(t.TempDir(), "usage.json"))
+	require.NoError(t, err)
+	tracker := &Tracker{
+		Store:    store,
+		Limits:   func() ([]Limit, error) { return limits, nil },
+		Interval: 30 * time.Second,
+		Warning:  2 * time.Minute,
+	}
+	return &testTracker{Tracker: tracker, devices: []*roku.Device{device}}, fake
+}
+
+func TestTracker_Accounting(t *testing.T) {
+	tracker, fake := newTestTracker(t)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.Local)
+
+	for i := 0; i <= 4; i++ {
+		require.NoError(t, tracker.Tick(ctx, start.Add(time.Duration(i)*30*time.Second)))
+	}
+	fake.set("PowerOn", `<app>Roku</app>`)
+	require.NoError(t, tracker.Tick(ctx, start.Add(150*time.Second)))
+	fake.set("DisplayOff", "")
+	require.NoError(t, tracker.Tick(ctx, start.Add(180*time.Second)))
+	// Time while off, and a gap while this machine slept, are not counted
+	fake.set("PowerOn", `<app id="837">YouTube</app>`)
+	require.NoError(t, tracker.Tick(ctx, start.Add(210*time.Second)))
+	require.NoError(t, tracker.Tick(ctx, start.Add(2*time.Hour)))
+
+	assert.Equal(t, 2*time.Minute, tracker.Store.Total(start, "192.168.1.21", "YouTube"))
+	assert.Equal(t, 2*time.Minute+30*time.Second, tracker.Store.Total(start, "192.168.1.21", ""))
+	assert.Empty(t, fake.takePosts())
+
+	// The store survives a reload
+	reopened, err := Open(tracker.Store.path)
+	require.NoError(t, err)
+	report := reopened.Report(start, start)
+	require.Len(t, report, 2)
+	assert.Equal(t, Entry{Day: "2026-10-19", Device: "192.168.1.21", AppID: "837", App: "YouTube", Duration: 2 * time.Minute}, report[0])
+	assert.Equal(t, HomeID, report[1].AppID)
+}
+
+func TestTracker_Limits(t *testing.T) {
+	t.Run("Device limit warns then powers off", func(t *testing.T) {
+		tracker, fake := newTestTracker(t, Limit{Device: "192.168.1.21", Daily: time.Minute})
+		ctx := context.Background()
+		start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.Local)
+
+		require.NoError(t, tracker.Tick(ctx, start))
+		require.NoError(t, tracker.Tick(ctx, start.Add(30*time.Second)))
+		assert.Empty(t, fake.takePosts())
+
+		require.NoError(t, tracker.Tick(ctx, start.Add(60*time.Second)))
+		assert.Equal(t, []string{"/input text=" + LimitInput, "/keypress/Home"}, fake.takePosts())
+
+		require.NoError(t, tracker.Tick(ctx, start.Add(90*time.Second)))
+		assert.Empty(t, fake.takePosts(), "waits for the warning period")
+
+		require.NoError(t, tracker.Tick(ctx, start.Add(180*time.Second)))
+		assert.Equal(t, []string{"/keypress/PowerOff"}, fake.takePosts())
+	})
+
+	t.Run("App limit only sends home", func(t *testing.T) {
+		tracker, fake := newTestTracker(t, Limit{App: "youtube", Daily: 30 * time.Second})
+		ctx := context.Background()
+		start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.Local)
+
+		require.NoError(t, tracker.Tick(ctx, start))
+		require.NoError(t, tracker.Tick(ctx, start.Add(30*time.Second)))
+		assert.Equal(t, []string{"/input text=" + LimitInput, "/keypress/Home"}, fake.takePosts())
+
+		// Other apps are unaffected
+		fake.set("PowerOn", `<app id="12">Netflix</app>`)
+		require.NoError(t, tracker.Tick(ctx, start.Add(3*time.Minute)))
+		assert.Empty(t, fake.takePosts())
+
+		// Going back to the app is sent home again after the warning period
+		fake.set("PowerOn", `<app id="837">YouTube</app>`)
+		require.NoError(t, tracker.Tick(ctx, start.Add(3*time.Minute+30*time.Second)))
+		assert.Equal(t, []string{"/keypress/Home"}, fake.takePosts())
+	})
+
+	t.Run("Limits are reloaded every round", func(t *testing.T) {
+		tracker, fake := newTestTracker(t)
+		ctx := context.Background()
+		start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.Local)
+
+		require.NoError(t, tracker.Tick(ctx, start))
+		require.NoError(t, tracker.Tick(ctx, start.Add(30*time.Second)))
+		assert.Empty(t, fake.takePosts())
+
+		limits := []Limit{{App: "youtube", Daily: 30 * time.Second}}
+		tracker.Limits = func() ([]Limit, error) { return limits, nil }
+		require.NoError(t, tracker.Tick(ctx, start.Add(60*time.Second)))
+		assert.Equal(t, []string{"/input text=" + LimitInput, "/keypress/Home"}, fake.takePosts())
+
+		// A config error keeps the last limits loaded
+		tracker.Limits = func() ([]Limit, error) { return nil, errors.New("bad config") }
+		require.NoError(t, tracker.Tick(ctx, start.Add(5*time.Minute)))
+		assert.Equal(t, []string{"/keypress/Home"}, fake.takePosts())
+	})
+}
@@ -0,0 +1,269 @@
+package roku
+
+import (
+	"context"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku/api"
+)
+
+// DefaultWatchInterval is how often the active app and player are polled
+const DefaultWatchInterval = 2 * time.Second
+
+// DefaultPowerInterval is how often device-info is polled for the power mode
+const DefaultPowerInterval = 15 * time.Second
+
+// EventType identifies the kind of state change reported by Watch
+type EventType string
+
+const (
+	EventAppChanged      EventType = "app_changed"
+	EventPlaybackStarted EventType = "playback_started"
+	EventPaused          EventType = "paused"
+	EventStopped         EventType = "stopped"
+	EventPowerChanged    EventType = "power_changed"
+	EventError           EventType = "error"
+	// EventInitial carries the state from the first poll, see WatchOptions.Initial
+	EventInitial EventType = "initial"
+)
+
+// Event is a single state change observed on a device
+type Event struct {
+	Type   EventType `json:"type"`
+	Time   time.Time `json:"time"`
+	Device string    `json:"device"`
+	From   string    `json:"from,omitempty"`
+	To     string    `json:"to,omitempty"`
+	App    *api.App  `json:"app,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	// State is set on EventInitial
+	State *Snapshot `json:"state,omitempty"`
+}
+
+// Snapshot is the full device state Watch starts diffing from
+type Snapshot struct {
+	PowerMode   string  `json:"power_mode,omitempty"`
+	App         api.App `json:"app"`
+	PlayerState string  `json:"player_state,omitempty"`
+}
+
+// WatchOptions configures how a device is polled for changes
+type WatchOptions struct {
+	// Interval between active app and media player polls
+	Interval time.Duration
+	// PowerInterval between device-info polls, which are larger and change rarely
+	PowerInterval time.Duration
+	// Initial emits an EventInitial with the state of the first successful
+	// poll, so consumers start from the same state the changes are diffed
+	// against
+	Initial bool
+}
+
+// watchState is the subset of device state that Watch diffs between polls
+type watchState struct {
+	app         api.App
+	playerState string
+	powerMode   string
+}
+
+// Watch polls the device and emits an Event whenever the active app, player
+// state or power mode changes. When the device has a live ECP-2 session, player
+// and power changes are taken from pushed notifications instead of polling,
+// with a full poll whenever the session reports notifications were lost.
+// ECP does not expose the volume level, so volume changes cannot be observed.
+// The channel is closed when ctx is cancelled.
+func (d *Device) Watch(ctx context.Context, opts WatchOptions) <-chan Event {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWatchInterval
+	}
+	if opts.PowerInterval <= 0 {
+		opts.PowerInterval = DefaultPowerInterval
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+
+		var notifications <-chan api.Notification
+		var lost <-chan struct{}
+		if d.sessionActive() {
+			err := d.Session.Subscribe(ctx, api.NotifyMediaPlayerStateChanged, api.NotifyPowerModeChanged)
+			if err == nil {
+				notifications = d.Session.Notifications()
+				lost = d.Session.Lost()
+			}
+		}
+
+		var prev *watchState
+		var lastErr string
+		var lastPower time.Time
+
+		emit := func(batch []Event) bool {
+			now := time.Now()
+			for _, e := range batch {
+				e.Time = now
+				e.Device = d.IP
+				select {
+				case events <- e:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		// tick polls the device. full re-queries the player and power mode
+		// even while they are pushed, for when notifications were lost.
+		tick := func(full bool) bool {
+			// Start from the previous state so values that are not re-polled
+			// this round (power mode, or app and player while off) carry over
+			next := watchState{}
+			if prev != nil {
+				next = *prev
+			}
+			pushed := notifications != nil && prev != nil && !full
+			refreshPower := prev == nil || full || (!pushed && time.Since(lastPower) >= opts.PowerInterval)
+			err := d.poll(ctx, &next, refreshPower, !pushed)
+			if err != nil {
+				if ctx.Err() != nil {
+					return false
+				}
+				if err.Error() == lastErr {
+					return true
+				}
+				lastErr = err.Error()
+				return emit([]Event{{Type: EventError, Error: err.Error()}})
+			}
+			if refreshPower {
+				lastPower = time.Now()
+			}
+			lastErr = ""
+			var batch []Event
+			switch {
+			case prev != nil:
+				batch = diffState(*prev, next)
+			case opts.Initial:
+				batch = []Event{{Type: EventInitial, State: &Snapshot{
+					PowerMode: next.powerMode, App: next.app, PlayerState: next.playerState,
+				}}}
+			}
+			prev = &next
+			return emit(batch)
+		}
+
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		if !tick(false) {
+			return
+		}
+		for {
+			select {
+			case <-ticker.C:
+				if !tick(false) {
+					return
+				}
+			case <-lost:
+				// Pushed changes were dropped, so poll everything to catch up
+				if !tick(true) {
+					return
+				}
+			case n, ok := <-notifications:
+				if !ok {
+					// Session ended, fall back to polling everything
+					notifications = nil
+					lost = nil
+					continue
+				}
+				if prev == nil {
+					continue
+				}
+				next := *prev
+				applyNotification(&next, n)
+				batch := diffState(*prev, next)
+				prev = &next
+				if !emit(batch) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// poll fills in the current state. The player is only queried while the
+// device is on and pollPlayer is set, and device-info only when refreshPower
+// is set.
+func (d *Device) poll(ctx context.Context, s *watchState, refreshPower, pollPlayer bool) error {
+	if refreshPower {
+		info, err := d.DeviceInfo(ctx)
+		if err != nil {
+			return err
+		}
+		s.powerMode = info.PowerMode
+	}
+	if s.powerMode != "" && s.powerMode != "PowerOn" {
+		return nil
+	}
+
+	active, err := d.ActiveApp(ctx)
+	if err != nil {
+		return err
+	}
+	s.app = active.App
+
+	if !pollPlayer && !refreshPower {
+		return nil
+	}
+	player, err := d.Player(ctx)
+	if err != nil {
+		return err
+	}
+	s.playerState = player.State
+	return nil
+}
+
+// applyNotification updates the state from a pushed ECP-2 notification
+func applyNotification(s *watchState, n api.Notification) {
+	switch n.Name {
+	case api.NotifyMediaPlayerStateChanged:
+		s.playerState = n.Params["param-media-player-state"]
+	case api.NotifyPowerModeChanged:
+		s.powerMode = n.Params["param-power-mode"]
+	}
+}
+
+// diffState returns the events describing the transition from prev to next
+func diffState(prev, next watchState) []Event {
+	var events []Event
+	if prev.powerMode != next.powerMode {
+		events = append(events, Event{Type: EventPowerChanged, From: prev.powerMode, To: next.powerMode})
+	}
+	if prev.app.ID != next.app.ID {
+		app := next.app
+		events = append(events, Event{Type: EventAppChanged, From: prev.app.Name, To: next.app.Name, App: &app})
+	}
+	if prev.playerState != next.playerState {
+		e := Event{From: prev.playerState, To: next.playerState}
+		switch next.playerState {
+		case "play":
+			e.Type = EventPlaybackStarted
+		case "pause":
+			e.Type = EventPaused
+		case "stop", "close", "none", "":
+			if prev.playerState != "play" && prev.playerState != "pause" {
+				return events
+			}
+			e.Type = EventStopped
+		default:
+			return events
+		}
+		if next.app.ID != "" {
+			app := next.app
+			e.App = &app
+		}
+		events = append(events, e)
+	}
+	return events
+}
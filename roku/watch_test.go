@@ -0,0 +1,121 @@
+package roku
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grahamplata/roku-remote/roku/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffState(t *testing.T) {
+	netflix := api.App{ID: "12", Name: "Netflix"}
+	youtube := api.App{ID: "837", Name: "YouTube"}
+
+	tests := []struct {
+		name     string
+		prev     watchState
+		next     watchState
+		expected []EventType
+	}{
+		{"NoChange", watchState{app: netflix, playerState: "play", powerMode: "PowerOn"}, watchState{app: netflix, playerState: "play", powerMode: "PowerOn"}, nil},
+		{"AppChanged", watchState{app: netflix}, watchState{app: youtube}, []EventType{EventAppChanged}},
+		{"PlaybackStarted", watchState{app: netflix, playerState: "pause"}, watchState{app: netflix, playerState: "play"}, []EventType{EventPlaybackStarted}},
+		{"Paused", watchState{app: netflix, playerState: "play"}, watchState{app: netflix, playerState: "pause"}, []EventType{EventPaused}},
+		{"Stopped", watchState{app: netflix, playerState: "play"}, watchState{app: netflix, playerState: "close"}, []EventType{EventStopped}},
+		{"IgnoredTransition", watchState{app: netflix, playerState: "close"}, watchState{app: netflix, playerState: "buffer"}, nil},
+		{"PowerChanged", watchState{powerMode: "PowerOn"}, watchState{powerMode: "DisplayOff"}, []EventType{EventPowerChanged}},
+		{"Multiple", watchState{app: netflix, playerState: "play", powerMode: "PowerOn"}, watchState{app: youtube, playerState: "close", powerMode: "PowerOn"}, []EventType{EventAppChanged, EventStopped}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var types []EventType
+			for _, e := range diffState(tt.prev, tt.next) {
+				types = append(types, e.Type)
+			}
+			assert.Equal(t, tt.expected, types)
+		})
+	}
+}
+
+func TestApplyNotification(t *testing.T) {
+	s := watchState{playerState: "play", powerMode: "PowerOn"}
+
+	applyNotification(&s, api.Notification{Name: api.NotifyMediaPlayerStateChanged, Params: map[string]string{"param-media-player-state": "pause"}})
+	applyNotification(&s, api.Notification{Name: api.NotifyPowerModeChanged, Params: map[string]string{"param-power-mode": "DisplayOff"}})
+	applyNotification(&s, api.Notification{Name: "language-changed"})
+
+	assert.Equal(t, "pause", s.playerState)
+	assert.Equal(t, "DisplayOff", s.powerMode)
+}
+
+func TestDevice_Watch(t *testing.T) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		switch r.URL.Path {
+		case api.EndpointDeviceInfo:
+			fmt.Fprint(w, `<device-info><power-mode>PowerOn</power-mode></device-info>`)
+		case api.EndpointActiveApp:
+			polls.Add(1)
+			fmt.Fprint(w, `<active-app><app id="12">Netflix</app></active-app>`)
+		case api.EndpointMediaPlayer:
+			state := "pause"
+			if polls.Load() > 1 {
+				state = "play"
+			}
+			fmt.Fprintf(w, `<player state="%s"/>`, state)
+		}
+	}))
+	defer server.Close()
+
+	device := createTestDevice(server)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := device.Watch(ctx, WatchOptions{Interval: 10 * time.Millisecond})
+
+	event, ok := <-events
+	require.True(t, ok)
+	assert.Equal(t, EventPlaybackStarted, event.Type)
+	assert.Equal(t, "pause", event.From)
+	assert.Equal(t, "play", event.To)
+	assert.Equal(t, "127.0.0.1", event.Device)
+	require.NotNil(t, event.App)
+	assert.Equal(t, "Netflix", event.App.Name)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestDevice_WatchInitial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case api.EndpointDeviceInfo:
+			fmt.Fprint(w, `<device-info><power-mode>PowerOn</power-mode></device-info>`)
+		case api.EndpointActiveApp:
+			fmt.Fprint(w, `<active-app><app id="12">Netflix</app></active-app>`)
+		case api.EndpointMediaPlayer:
+			fmt.Fprint(w, `<player state="play"/>`)
+		}
+	}))
+	defer server.Close()
+
+	device := createTestDevice(server)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	event, ok := <-device.Watch(ctx, WatchOptions{Interval: time.Hour, Initial: true})
+	require.True(t, ok)
+	assert.Equal(t, EventInitial, event.Type)
+	assert.Equal(t, &Snapshot{PowerMode: "PowerOn", App: api.App{ID: "12", Name: "Netflix"}, PlayerState: "play"}, event.State)
+	cancel()
+}